@@ -3,7 +3,6 @@ package tag
 import (
 	"errors"
 	"strings"
-	"unicode"
 )
 
 // state is an enum type
@@ -18,92 +17,178 @@ const (
 	vsq              // Attribute value (single-quoted) state
 	v                // Attribute value (unquoted) state
 	avq              // After attribute value (quoted) state
+	sc               // Self-closing start tag state
 )
 
+// errUnexpectedChar is returned by the states when a character is not allowed at the current position
+var errUnexpectedChar = errors.New("unexpected char")
+
 // parse is a struct representing the current state of a parsing process
 type parse struct {
-	i     int               //a current position in the string
+	s     string            // a parsed string
+	i     int               // a current position in the string
 	r     rune              // a current rune
+	mark  int               // a position where the current name or value starts
 	name  string            // a current name
 	value string            // a current value
-	attr  map[string]string // a map of saved attributes
+	attr  map[string]string // a map of saved attributes; nil if the attributes are not saved
 	state state             // a current state
+	err   error             // the first error returned by a state
+	self  bool              // true if the tag is closed with "/>"
 }
 
 // parseAttribute parses the s string into a map of attributes. The attribute name is always changed to lowercase.
+// The character references in the attribute values are replaced by the characters they represent.
+func parseAttribute(s string) map[string]string {
+	attr := parseRawAttribute(s)
+	for k, v := range attr {
+		attr[k] = unescape(v, true)
+	}
+
+	return attr
+}
+
+// parseRawAttribute parses the s string into a map of attributes. The attribute name is always changed to lowercase.
+// The attribute values are left as they are written in s.
 //
 // Loosely inspired by the algorithm described here:
 // https://html.spec.whatwg.org/multipage/parsing.html#before-attribute-name-state
-func parseAttribute(s string) map[string]string {
+func parseRawAttribute(s string) map[string]string {
 
 	// start with an empty map and the starting state is: Before attribute name state
 	p := parse{
+		s:     s,
 		attr:  map[string]string{},
 		state: bn,
 	}
 
+	p.run()
+
+	if p.err != nil {
+		// the attributes are malformed
+		return map[string]string{}
+	}
+
+	return p.attr
+}
+
+// tagEnd returns the index of the next character after the closure of a tag, whose attributes start at the beginning of s.
+// The closure is the first '>' which is not a part of a quoted attribute value.
+// selfClosing is true if the tag is closed with "/>", e.g. <br/>.
+// Returns -1 if the tag is not closed in s.
+func tagEnd(s string) (end int, selfClosing bool) {
+	// the attributes are not saved
+	p := parse{
+		s:     s,
+		state: bn,
+	}
+
+	end = p.run()
+
+	return end, p.self
+}
+
+// run parses p.s until the closure of the tag or the end of p.s.
+// It returns the index of the next character after the closure, or -1 if there is no closure.
+func (p *parse) run() int {
 	// read all the characters one by one
-	for ; p.i < len(s); p.i++ {
+	for ; p.i < len(p.s); p.i++ {
 
 		// read a rune
-		p.r = rune(s[p.i])
+		p.r = rune(p.s[p.i])
+
+		if p.r == '>' && p.state != vdq && p.state != vsq {
+			// the closure of the tag
+			p.self = p.state == sc
+			p.finish()
+			return p.i + 1
+		}
 
 		// choose a state
+		var err error
 		switch p.state {
 		case bn:
 			// Before attribute name state
-			if beforeName(&p) != nil {
-				return map[string]string{}
-			}
+			err = beforeName(p)
 		case n:
 			// Attribute name state
-			if name(&p) != nil {
-				return map[string]string{}
-			}
+			err = name(p)
 		case an:
 			// After attribute name state
-			afterName(&p)
+			afterName(p)
 		case bv:
 			// Before attribute value state
-			beforeValue(&p)
+			beforeValue(p)
 		case vdq:
 			// Attribute value (double-quoted) state
-			valueDQ(&p)
+			valueDQ(p)
 		case vsq:
 			// Attribute value (single-quoted) state
-			valueSQ(&p)
+			valueSQ(p)
 		case v:
 			// Attribute value (unquoted) state
-			if value(&p) != nil {
-				return map[string]string{}
-			}
+			err = value(p)
 		case avq:
 			// After attribute value (quoted) state
-			if afterValueQ(&p) != nil {
-				return map[string]string{}
-			}
+			err = afterValueQ(p)
+		case sc:
+			// Self-closing start tag state
+			err = selfClosing(p)
+		}
+
+		// remember the first error, but continue to find the closure
+		if err != nil && p.err == nil {
+			p.err = err
 		}
 	}
 
-	// append the last attribute
-	if p.name != "" {
-		p.attr[p.name] = p.value
+	p.finish()
+	return -1
+}
+
+// finish appends the last attribute
+func (p *parse) finish() {
+	switch p.state {
+	case n:
+		p.name = toLowerASCII(p.s[p.mark:p.i])
+	case v, vdq, vsq:
+		p.value = p.s[p.mark:p.i]
+	case an, bv:
+		// the attribute has no value
+	default:
+		// there is no attribute
+		return
 	}
 
-	return p.attr
+	p.emit()
+}
+
+// emit saves the current attribute and clears its name and value
+func (p *parse) emit() {
+	if p.attr != nil {
+		p.attr[p.name] = p.value
+	}
+	p.name = ""
+	p.value = ""
 }
 
 func beforeName(p *parse) error {
 	// Before attribute name state
 	switch {
-	case unicode.IsSpace(p.r):
+	case isSpace(p.s[p.i]):
 		// ignore the character
+	case p.r == '/':
+		// switch to the self-closing start tag state
+		p.state = sc
 	case p.r == '=':
-		// unexpected char
-		return errors.New("unexpected char")
+		// unexpected char; it starts the attribute name
+		p.state = n
+		p.mark = p.i
+		return errUnexpectedChar
 	default:
 		// reconsume in the attribute name state
 		p.state = n
+		p.mark = p.i
 		p.i--
 	}
 
@@ -113,17 +198,24 @@ func beforeName(p *parse) error {
 func name(p *parse) error {
 	// Attribute name state
 	switch {
-	case unicode.IsSpace(p.r):
+	case isSpace(p.s[p.i]):
 		// switch to the after attribute name state
+		p.name = toLowerASCII(p.s[p.mark:p.i])
 		p.state = an
+	case p.r == '/':
+		// reconsume in the after attribute name state
+		p.name = toLowerASCII(p.s[p.mark:p.i])
+		p.state = an
+		p.i--
 	case p.r == '=':
 		// switch to the before attribute value state
+		p.name = toLowerASCII(p.s[p.mark:p.i])
 		p.state = bv
 	case p.r == 0 || p.r == '"' || p.r == '\'' || p.r == '<':
-		// unexpected char
-		return errors.New("unexpected char")
+		// unexpected char; it is a part of the name
+		return errUnexpectedChar
 	default:
-		p.name += string(unicode.ToLower(p.r))
+		// the character is a part of the name
 	}
 
 	return nil
@@ -132,16 +224,20 @@ func name(p *parse) error {
 func afterName(p *parse) {
 	// After attribute name state
 	switch {
-	case unicode.IsSpace(p.r):
+	case isSpace(p.s[p.i]):
 		// ignore the character
 	case p.r == '=':
 		// switch to the before attribute value state
 		p.state = bv
+	case p.r == '/':
+		// emit attribute without value; switch to the self-closing start tag state
+		p.emit()
+		p.state = sc
 	default:
 		// emit attribute without value; reconsume in the attribute name state
+		p.emit()
 		p.state = n
-		p.attr[p.name] = ""
-		p.name = ""
+		p.mark = p.i
 		p.i--
 	}
 }
@@ -149,17 +245,20 @@ func afterName(p *parse) {
 func beforeValue(p *parse) {
 	// Before attribute value state
 	switch {
-	case unicode.IsSpace(p.r):
+	case isSpace(p.s[p.i]):
 		// ignore the character
 	case p.r == '"':
 		// switch to the attribute value (double-quoted) state
 		p.state = vdq
+		p.mark = p.i + 1
 	case p.r == '\'':
 		// switch to the attribute value (single-quoted) state
 		p.state = vsq
+		p.mark = p.i + 1
 	default:
 		// reconsume in the attribute value (unquoted) state
 		p.state = v
+		p.mark = p.i
 		p.i--
 	}
 }
@@ -168,14 +267,12 @@ func valueDQ(p *parse) {
 	// Attribute value (double-quoted) state
 	switch {
 	case p.r == '"':
-		// emit attribute without value; switch to the after attribute value (quoted) state
-		p.attr[p.name] = p.value
-		p.name = ""
-		p.value = ""
+		// emit attribute; switch to the after attribute value (quoted) state
+		p.value = p.s[p.mark:p.i]
+		p.emit()
 		p.state = avq
 	default:
-		// append the current input character to the current attribute's value
-		p.value += string(p.r)
+		// the character is a part of the value
 	}
 }
 
@@ -183,32 +280,28 @@ func valueSQ(p *parse) {
 	// Attribute value (single-quoted) state
 	switch {
 	case p.r == '\'':
-		// emit attribute without value; switch to the after attribute value (quoted) state
-		p.attr[p.name] = p.value
-		p.name = ""
-		p.value = ""
+		// emit attribute; switch to the after attribute value (quoted) state
+		p.value = p.s[p.mark:p.i]
+		p.emit()
 		p.state = avq
 	default:
-		// append the current input character to the current attribute's value
-		p.value += string(p.r)
+		// the character is a part of the value
 	}
 }
 
 func value(p *parse) error {
 	// Attribute value (unquoted) state
 	switch {
-	case unicode.IsSpace(p.r):
-		// emit attribute without value; switch to the before attribute name state
-		p.attr[p.name] = p.value
-		p.name = ""
-		p.value = ""
+	case isSpace(p.s[p.i]):
+		// emit attribute; switch to the before attribute name state
+		p.value = p.s[p.mark:p.i]
+		p.emit()
 		p.state = bn
-	case strings.ContainsAny(string(p.r), "\"'<=`"):
-		// unexpected char
-		return errors.New("unexpected char")
+	case strings.ContainsRune("\"'<=`", p.r):
+		// unexpected char; it is a part of the value
+		return errUnexpectedChar
 	default:
-		// append the current input character to the current attribute's value
-		p.value += string(p.r)
+		// the character is a part of the value
 	}
 
 	return nil
@@ -217,17 +310,27 @@ func value(p *parse) error {
 func afterValueQ(p *parse) error {
 	// After attribute value (quoted) state
 	switch {
-	case unicode.IsSpace(p.r):
+	case isSpace(p.s[p.i]):
 		// switch to the before attribute name state
 		p.state = bn
 	case p.r == '/':
-		// reconsume in the attribute value (unquoted) state
-		p.state = n
-		p.i--
+		// switch to the self-closing start tag state
+		p.state = sc
 	default:
-		// unexpected char
-		return errors.New("unexpected char")
+		// unexpected char; reconsume in the before attribute name state
+		p.state = bn
+		p.i--
+		return errUnexpectedChar
 	}
 
 	return nil
 }
+
+func selfClosing(p *parse) error {
+	// Self-closing start tag state; the closure is handled by run
+	// unexpected char; reconsume in the before attribute name state
+	p.state = bn
+	p.i--
+
+	return errUnexpectedChar
+}
@@ -1,6 +1,9 @@
 package tag
 
-import "strings"
+import (
+	"regexp"
+	"strings"
+)
 
 // Check is a type of function that takes *Tag as an argument and returns a boolean value.
 // []Check is used as an argument for the Find function.
@@ -56,3 +59,240 @@ func NotEmpty(attr string) Check {
 		return true
 	}
 }
+
+// AllOf determines if the tag satisfies all f functions.
+// It is true if there are no functions, like an empty []Check.
+func AllOf(f ...Check) Check {
+	return func(t *Tag) bool {
+		return passChecks(f, t)
+	}
+}
+
+// AnyOf determines if the tag satisfies at least one of the f functions.
+// It is false if there are no functions.
+func AnyOf(f ...Check) Check {
+	return func(t *Tag) bool {
+		for _, check := range f {
+			if check(t) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// Or is the same as AnyOf.
+func Or(f ...Check) Check {
+	return AnyOf(f...)
+}
+
+// Not determines if the tag does not satisfy the f function.
+func Not(f Check) Check {
+	return func(t *Tag) bool {
+		return !f(t)
+	}
+}
+
+// None determines if the tag does not satisfy any of the f functions.
+// It is true if there are no functions.
+func None(f ...Check) Check {
+	return Not(AnyOf(f...))
+}
+
+// HasToken determines if the value of the attr attribute, which is a list of tokens separated by white spaces (e.g. rel or itemprop),
+// contains the token. Unlike Contains, it does not match a part of a token.
+// Returns false if the attribute does not exist.
+// attr is case-insensitive.
+func HasToken(attr, token string) Check {
+	return func(t *Tag) bool {
+		return hasField(t.Attr[strings.ToLower(attr)], token)
+	}
+}
+
+// HasClass determines if the tag has the class of the given name, e.g. HasClass("btn") is satisfied by class="btn btn-primary",
+// but not by class="btn-primary".
+func HasClass(name string) Check {
+	return HasToken("class", name)
+}
+
+// HasAllClasses determines if the tag has all classes of the given names, in any order.
+func HasAllClasses(names ...string) Check {
+	return func(t *Tag) bool {
+		for _, name := range names {
+			if !hasField(t.Attr["class"], name) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// Matches determines if the value of the attr attribute matches the regular expression re.
+// Returns false if the attribute does not exist.
+// attr is case-insensitive.
+func Matches(attr string, re *regexp.Regexp) Check {
+	return func(t *Tag) bool {
+		v, ok := t.Attr[strings.ToLower(attr)]
+		if !ok {
+			return false
+		}
+
+		return re.MatchString(v)
+	}
+}
+
+// HasPrefix determines if the value of the attr attribute begins with the s string, like [attr^=s] in CSS.
+// Returns false if the attribute does not exist or s is empty.
+// attr is case-insensitive.
+func HasPrefix(attr, s string) Check {
+	return attrOperator(attr, "^=", s, false)
+}
+
+// HasSuffix determines if the value of the attr attribute ends with the s string, like [attr$=s] in CSS.
+// Returns false if the attribute does not exist or s is empty.
+// attr is case-insensitive.
+func HasSuffix(attr, s string) Check {
+	return attrOperator(attr, "$=", s, false)
+}
+
+// DashMatch determines if the value of the attr attribute is equal to the s string or begins with s followed by '-',
+// like [attr|=s] in CSS, e.g. DashMatch("lang", "en") is satisfied by lang="en-US".
+// Returns false if the attribute does not exist.
+// attr is case-insensitive.
+func DashMatch(attr, s string) Check {
+	return attrOperator(attr, "|=", s, false)
+}
+
+// EqualFold determines if the value of the attr attribute is equal to the s string, ignoring the case of ASCII letters, like [attr=s i] in CSS.
+// Returns false if the attribute does not exist.
+// attr is case-insensitive.
+func EqualFold(attr, s string) Check {
+	return attrOperator(attr, "=", s, true)
+}
+
+// ContainsFold determines if the value of the attr attribute contains the s string, ignoring the case of ASCII letters, like [attr*=s i] in CSS.
+// Returns false if the attribute does not exist or s is empty.
+// attr is case-insensitive.
+func ContainsFold(attr, s string) Check {
+	return attrOperator(attr, "*=", s, true)
+}
+
+// attrOperator returns a Check which compares the value of the attr attribute with the s string using the CSS attribute selector operator op
+func attrOperator(attr, op, s string, fold bool) Check {
+	return func(t *Tag) bool {
+		v, ok := t.Attr[strings.ToLower(attr)]
+		if !ok {
+			return false
+		}
+
+		return matchAttr(op, v, s, fold)
+	}
+}
+
+// TextEquals determines if the text of the tag's content is equal to the s string.
+// The text is the one returned by Text, with leading and trailing white spaces removed and other sequences of white spaces,
+// including new lines, replaced by a single space, e.g. "Next page" for <a>\n  Next <b>page</b>\n</a>.
+func TextEquals(s string) Check {
+	return func(t *Tag) bool {
+		return t.text() == s
+	}
+}
+
+// TextContains determines if the text of the tag's content contains the s string.
+// The text is prepared as in TextEquals.
+func TextContains(s string) Check {
+	return func(t *Tag) bool {
+		return strings.Contains(t.text(), s)
+	}
+}
+
+// TextMatches determines if the text of the tag's content matches the regular expression re.
+// The text is prepared as in TextEquals.
+func TextMatches(re *regexp.Regexp) Check {
+	return func(t *Tag) bool {
+		return re.MatchString(t.text())
+	}
+}
+
+// ContentContains determines if the content of the tag, as returned by Content, contains the s string.
+func ContentContains(s string) Check {
+	return func(t *Tag) bool {
+		return strings.Contains(t.Content(), s)
+	}
+}
+
+// HasChild determines if the tag has a child element of the given name which satisfies all f functions.
+// The elements are found in the document where the tag was found. name is case-insensitive; "*" matches any element.
+func HasChild(name string, f ...Check) Check {
+	return structural(name, f, func(n *Node, match func(*Node) bool) bool {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if match(c) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// HasDescendant determines if the tag contains an element of the given name which satisfies all f functions, at any depth.
+// The elements are found in the document where the tag was found. name is case-insensitive; "*" matches any element.
+func HasDescendant(name string, f ...Check) Check {
+	return structural(name, f, func(n *Node, match func(*Node) bool) bool {
+		for d := n.FirstChild; d != nil; d = nextInOrder(d, n) {
+			if match(d) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// HasParent determines if the parent of the tag is an element of the given name which satisfies all f functions.
+// The elements are found in the document where the tag was found. name is case-insensitive; "*" matches any element.
+func HasParent(name string, f ...Check) Check {
+	return structural(name, f, func(n *Node, match func(*Node) bool) bool {
+		return match(n.Parent)
+	})
+}
+
+// HasAncestor determines if the tag is inside an element of the given name which satisfies all f functions, at any depth.
+// The elements are found in the document where the tag was found. name is case-insensitive; "*" matches any element.
+func HasAncestor(name string, f ...Check) Check {
+	return structural(name, f, func(n *Node, match func(*Node) bool) bool {
+		for a := n.Parent; a != nil; a = a.Parent {
+			if match(a) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// InsideOf is the same as HasAncestor.
+func InsideOf(name string, f ...Check) Check {
+	return HasAncestor(name, f...)
+}
+
+// structural returns a Check which calls related with the element of the tag in its document's tree,
+// and a function matching the elements of the given name which satisfy all f functions.
+// The tree is held by the tag, so it is parsed once for the tags found by the same search.
+func structural(name string, f []Check, related func(n *Node, match func(*Node) bool) bool) Check {
+	name = toLowerASCII(name)
+	match := func(n *Node) bool {
+		if n.Type != ElementNode || (name != "*" && n.Name != name) {
+			return false
+		}
+		return passChecks(f, n.Tag())
+	}
+
+	return func(t *Tag) bool {
+		n := t.node()
+		if n == nil {
+			return false
+		}
+
+		return related(n, match)
+	}
+}
@@ -0,0 +1,212 @@
+package tag
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestCombinators(t *testing.T) {
+	tag := &Tag{Name: "a", Attr: map[string]string{"class": "btn", "role": "link"}}
+
+	yes := Equal("class", "btn")
+	no := Equal("role", "button")
+
+	tests := []struct {
+		name  string
+		check Check
+		want  bool
+	}{
+		{"AllOf", AllOf(yes, Has("role")), true},
+		{"AllOf/false", AllOf(yes, no), false},
+		{"AllOf/empty", AllOf(), true},
+		{"AnyOf", AnyOf(no, yes), true},
+		{"AnyOf/false", AnyOf(no, Has("href")), false},
+		{"AnyOf/empty", AnyOf(), false},
+		{"Or", Or(no, yes), true},
+		{"Or/false", Or(no), false},
+		{"Not", Not(no), true},
+		{"Not/false", Not(yes), false},
+		{"None", None(no, Has("href")), true},
+		{"None/false", None(no, yes), false},
+		{"None/empty", None(), true},
+		{"nested", AllOf(Or(no, AllOf(yes, Not(no))), None(Has("href"))), true},
+		{"nested/false", Or(AllOf(yes, no), Not(AnyOf(yes, no))), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.check(tag); got != tt.want {
+				t.Errorf("%s() = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+
+	// the combinators are used by Next like any other checks
+	doc := `<a class=btn>1</a><a role=button>2</a><a class=btn role=button>3</a><a>4</a>`
+	var got string
+	for a := Find(doc, "a", []Check{Or(Equal("class", "btn"), Equal("role", "button")), Not(AllOf(Has("class"), Has("role")))}); a != nil; a = a.Next() {
+		got += a.Content()
+	}
+	if got != "12" {
+		t.Errorf("Find().Next() = %q, want %q", got, "12")
+	}
+}
+
+func TestTokenChecks(t *testing.T) {
+	tag := &Tag{Name: "a", Attr: map[string]string{"class": "\tbtn  btn-primary\nbig\u00a0x ", "rel": "nofollow noopener"}}
+
+	tests := []struct {
+		name  string
+		check Check
+		want  bool
+	}{
+		{"HasClass", HasClass("btn"), true},
+		{"HasClass/other", HasClass("btn-primary"), true},
+		{"HasClass/part", HasClass("primary"), false},
+		{"HasClass/prefix", HasClass("bt"), false},
+		{"HasClass/non-ASCII space", HasClass("big"), false},
+		{"HasClass/empty", HasClass(""), false},
+		{"HasAllClasses", HasAllClasses("btn-primary", "btn"), true},
+		{"HasAllClasses/false", HasAllClasses("btn", "nobtn"), false},
+		{"HasAllClasses/empty", HasAllClasses(), true},
+		{"HasToken", HasToken("REL", "noopener"), true},
+		{"HasToken/false", HasToken("rel", "follow"), false},
+		{"HasToken/missing", HasToken("itemprop", "name"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.check(tag); got != tt.want {
+				t.Errorf("%s() = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOperatorChecks(t *testing.T) {
+	tag := &Tag{Name: "a", Attr: map[string]string{"href": "https://Example.com/page?p=2", "lang": "en-US", "empty": ""}}
+
+	tests := []struct {
+		name  string
+		check Check
+		want  bool
+	}{
+		{"Matches", Matches("href", regexp.MustCompile(`\?p=\d+$`)), true},
+		{"Matches/false", Matches("HREF", regexp.MustCompile(`^http:`)), false},
+		{"Matches/missing", Matches("src", regexp.MustCompile(``)), false},
+		{"HasPrefix", HasPrefix("href", "https://"), true},
+		{"HasPrefix/case", HasPrefix("href", "HTTPS://"), false},
+		{"HasPrefix/empty", HasPrefix("href", ""), false},
+		{"HasSuffix", HasSuffix("Href", "p=2"), true},
+		{"HasSuffix/false", HasSuffix("href", "p=3"), false},
+		{"HasSuffix/empty", HasSuffix("empty", ""), false},
+		{"DashMatch", DashMatch("lang", "en"), true},
+		{"DashMatch/equal", DashMatch("lang", "en-US"), true},
+		{"DashMatch/prefix", DashMatch("lang", "e"), false},
+		{"DashMatch/empty", DashMatch("empty", ""), true},
+		{"EqualFold", EqualFold("lang", "EN-us"), true},
+		{"EqualFold/false", EqualFold("lang", "en"), false},
+		{"EqualFold/missing", EqualFold("title", ""), false},
+		{"ContainsFold", ContainsFold("href", "example.COM"), true},
+		{"ContainsFold/false", ContainsFold("href", "example.org"), false},
+		{"ContainsFold/empty", ContainsFold("href", ""), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.check(tag); got != tt.want {
+				t.Errorf("%s() = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTextChecks(t *testing.T) {
+	doc := `<a id=1>
+	Next  <b>page</b>&nbsp;&raquo;
+</a><a id=2>Add to <!-- x --><i>cart</i><script>var s = "</a>";</script></a><a id=3><![CDATA[<b>]]> &amp; more</a><title id=4> A &lt;b&gt;  title</title><a id=5></a>`
+
+	tests := []struct {
+		name  string
+		n     string
+		check Check
+		want  string
+	}{
+		{"TextEquals", "a", TextEquals("Next page »"), "1"},
+		{"TextEquals/nested", "a", TextEquals("Add to cart"), "2"},
+		{"TextEquals/CDATA", "a", TextEquals("<b> & more"), "3"},
+		{"TextEquals/raw text", "title", TextEquals("A <b> title"), "4"},
+		{"TextEquals/empty", "a", TextEquals(""), "5"},
+		{"TextContains", "a", TextContains("to cart"), "2"},
+		{"TextContains/script", "a", TextContains("var"), ""},
+		{"TextMatches", "a", TextMatches(regexp.MustCompile(`^Next\s`)), "1"},
+		{"ContentContains", "a", ContentContains("<b>page</b>"), "1"},
+		{"ContentContains/script", "a", ContentContains("var s"), "2"},
+		{"ContentContains/false", "a", ContentContains("Next page"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			if tag := Find(doc, tt.n, []Check{tt.check}); tag != nil {
+				got = tag.Attr["id"]
+			}
+			if got != tt.want {
+				t.Errorf("Find() id = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStructuralChecks(t *testing.T) {
+	doc := `<nav id=n><a id=1 href=/>Home</a><ul id=u><li id=l1><a id=2 href=/a>A</a><li id=l2>B</ul></nav>` +
+		`<table id=t><tr id=r1><td><a id=3 class=download href=/f>F</a><tr id=r2><td>x</table><a id=4 href=/x>X</a>`
+
+	tests := []struct {
+		name  string
+		n     string
+		check Check
+		want  string
+	}{
+		{"HasChild", "nav", HasChild("a"), "n"},
+		{"HasChild/checks", "ul", HasChild("LI", Equal("id", "l2")), "u"},
+		{"HasChild/grandchild", "table", HasChild("td"), ""},
+		{"HasChild/any", "li", HasChild("*"), "l1"},
+		{"HasDescendant", "tr", HasDescendant("a", HasClass("download")), "r1"},
+		{"HasDescendant/implied end", "li", HasDescendant("*", TextEquals("B")), ""},
+		{"HasDescendant/none", "tr", HasDescendant("a", Equal("id", "4")), ""},
+		{"HasParent", "a", HasParent("li"), "2"},
+		{"HasParent/checks", "a", HasParent("nav", Equal("id", "n")), "1"},
+		{"HasParent/root", "table", HasParent("*"), ""},
+		{"HasAncestor", "a", HasAncestor("table"), "3"},
+		{"HasAncestor/not", "a", Not(HasAncestor("*")), "4"},
+		{"InsideOf", "a", AllOf(InsideOf("nav"), InsideOf("ul")), "2"},
+		{"nested", "nav", HasDescendant("a", HasParent("li", HasAncestor("nav"))), "n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			if tag := Find(doc, tt.n, []Check{tt.check}); tag != nil {
+				got = tag.Attr["id"]
+			}
+			if got != tt.want {
+				t.Errorf("Find() id = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// the checks work with Next
+	var got []string
+	for a := Find(doc, "a", []Check{InsideOf("nav")}); a != nil; a = a.Next() {
+		got = append(got, a.Attr["id"])
+	}
+	if strings.Join(got, " ") != "1 2" {
+		t.Errorf("Find().Next() ids = %q, want %q", got, "1 2")
+	}
+
+	// a check can be used with several documents; each search has its own tree
+	check := HasParent("li")
+	a2, a5 := Find(doc, "a", []Check{check}), Find(`<ul><li><a id=5>5</a></ul>`, "a", []Check{check})
+	if a2 == nil || a5 == nil || a2.Attr["id"] != "2" || a5.Attr["id"] != "5" {
+		t.Errorf("Find() = %v, %v, want ids 2 and 5", a2, a5)
+	} else if a2.tree == a5.tree || a2.tree.tree == nil {
+		t.Errorf("Find() tags share the tree of another search")
+	}
+}
@@ -0,0 +1,294 @@
+package tag
+
+import "sort"
+
+// voidElements is a set of elements which cannot have any content and closing tag.
+var voidElements = map[string]bool{
+	"area":   true,
+	"base":   true,
+	"br":     true,
+	"col":    true,
+	"embed":  true,
+	"hr":     true,
+	"img":    true,
+	"input":  true,
+	"link":   true,
+	"meta":   true,
+	"source": true,
+	"track":  true,
+	"wbr":    true,
+	// obsolete elements, which are still parsed as void
+	"basefont": true,
+	"bgsound":  true,
+	"frame":    true,
+	"keygen":   true,
+	"param":    true,
+}
+
+// htmlElements is a set of the elements defined by the HTML specification, including the obsolete ones.
+// The self-closing flag of these elements is ignored, e.g. <div/> is only an opening tag.
+var htmlElements = set(
+	"a", "abbr", "acronym", "address", "applet", "area", "article", "aside", "audio", "b", "base", "basefont",
+	"bdi", "bdo", "bgsound", "big", "blink", "blockquote", "body", "br", "button", "canvas", "caption", "center",
+	"cite", "code", "col", "colgroup", "data", "datalist", "dd", "del", "details", "dfn", "dialog", "dir", "div",
+	"dl", "dt", "em", "embed", "fieldset", "figcaption", "figure", "font", "footer", "form", "frame", "frameset",
+	"h1", "h2", "h3", "h4", "h5", "h6", "head", "header", "hgroup", "hr", "html", "i", "iframe", "image", "img",
+	"input", "ins", "kbd", "keygen", "label", "legend", "li", "link", "listing", "main", "map", "mark",
+	"marquee", "menu", "meta", "meter", "nav", "nobr", "noembed", "noframes", "noscript", "object", "ol",
+	"optgroup", "option", "output", "p", "param", "picture", "plaintext", "pre", "progress", "q", "rb", "rp",
+	"rt", "rtc", "ruby", "s", "samp", "script", "search", "section", "select", "slot", "small", "source", "span",
+	"strike", "strong", "style", "sub", "summary", "sup", "table", "tbody", "td", "template", "textarea",
+	"tfoot", "th", "thead", "time", "title", "tr", "track", "tt", "u", "ul", "var", "video", "wbr", "xmp",
+)
+
+// closedByOpeningTag checks if the element named n is closed by its opening tag.
+// It is true for the void elements and for self-closing elements which are not HTML elements, e.g. <path/> in SVG.
+func closedByOpeningTag(n string, selfClosing bool) bool {
+	return voidElements[n] || (selfClosing && !htmlElements[n])
+}
+
+// specialElements is a set of elements which have special parsing rules.
+// They stop the search for the elements closed implicitly by an opening tag, e.g. <li> does not close li outside the nearest ul.
+var specialElements = set(
+	"address", "applet", "area", "article", "aside", "base", "basefont", "bgsound", "blockquote", "body", "br",
+	"button", "caption", "center", "col", "colgroup", "dd", "details", "dir", "div", "dl", "dt", "embed",
+	"fieldset", "figcaption", "figure", "footer", "form", "frame", "frameset", "h1", "h2", "h3", "h4", "h5",
+	"h6", "head", "header", "hgroup", "hr", "html", "iframe", "img", "input", "keygen", "li", "link", "listing",
+	"main", "marquee", "menu", "meta", "nav", "noembed", "noframes", "noscript", "object", "ol", "p", "param",
+	"plaintext", "pre", "script", "search", "section", "select", "source", "style", "summary", "table", "tbody",
+	"td", "template", "textarea", "tfoot", "th", "thead", "title", "tr", "track", "ul", "wbr", "xmp",
+)
+
+// set returns a set of the names
+func set(names ...string) map[string]bool {
+	m := make(map[string]bool, len(names))
+	for _, n := range names {
+		m[n] = true
+	}
+
+	return m
+}
+
+var (
+	// closersOfP is a set of the opening tags which close the p element
+	closersOfP = set("address", "article", "aside", "blockquote", "center", "details", "dialog", "dir", "div", "dl", "dd", "dt",
+		"fieldset", "figcaption", "figure", "footer", "form", "h1", "h2", "h3", "h4", "h5", "h6", "header", "hgroup", "hr",
+		"li", "listing", "main", "menu", "nav", "ol", "p", "plaintext", "pre", "search", "section", "summary", "table", "ul", "xmp")
+	// tableCells is a set of the opening tags which close a table cell
+	tableCells = set("td", "th", "tr", "tbody", "thead", "tfoot")
+	// rubyText is a set of the opening tags which close the ruby annotations
+	rubyText = set("rb", "rt", "rtc", "rp")
+)
+
+// closedByStartTag maps the elements with an optional closing tag to the sets of opening tags which close them implicitly.
+//
+// The rules are described here:
+// https://html.spec.whatwg.org/multipage/syntax.html#optional-tags
+var closedByStartTag = map[string]map[string]bool{
+	"p":        closersOfP,
+	"li":       set("li"),
+	"dt":       set("dt", "dd"),
+	"dd":       set("dt", "dd"),
+	"td":       tableCells,
+	"th":       tableCells,
+	"tr":       set("tr", "tbody", "thead", "tfoot"),
+	"thead":    set("tbody", "thead", "tfoot"),
+	"tbody":    set("tbody", "thead", "tfoot"),
+	"tfoot":    set("tbody", "thead", "tfoot"),
+	"option":   set("option", "optgroup", "hr"),
+	"optgroup": set("optgroup", "hr"),
+	"rb":       rubyText,
+	"rt":       rubyText,
+	"rp":       rubyText,
+	"rtc":      set("rb", "rtc"),
+}
+
+// closedByEndTag maps the elements with an optional closing tag to the sets of closing tags of their parents, which close them implicitly.
+var closedByEndTag = map[string]map[string]bool{
+	"p": set("address", "article", "aside", "blockquote", "body", "button", "caption", "center", "dd", "details", "dialog", "div", "dl", "dt",
+		"fieldset", "figcaption", "figure", "footer", "form", "header", "html", "legend", "li", "main", "menu", "nav", "object", "ol",
+		"search", "section", "summary", "table", "tbody", "td", "template", "tfoot", "th", "thead", "tr", "ul"),
+	"li":       set("ul", "ol", "menu", "body", "html"),
+	"dt":       set("dl", "div", "body", "html"),
+	"dd":       set("dl", "div", "body", "html"),
+	"td":       set("tr", "tbody", "thead", "tfoot", "table", "body", "html"),
+	"th":       set("tr", "tbody", "thead", "tfoot", "table", "body", "html"),
+	"tr":       set("tbody", "thead", "tfoot", "table", "body", "html"),
+	"thead":    set("table", "body", "html"),
+	"tbody":    set("table", "body", "html"),
+	"tfoot":    set("table", "body", "html"),
+	"option":   set("select", "datalist", "optgroup", "body", "html"),
+	"optgroup": set("select", "body", "html"),
+	"rb":       set("ruby", "body", "html"),
+	"rt":       set("ruby", "rtc", "body", "html"),
+	"rp":       set("ruby", "rtc", "body", "html"),
+	"rtc":      set("ruby", "body", "html"),
+}
+
+// kindOf maps the elements to the names of the elements of the same kind.
+// An opening tag closes implicitly at most one element of its own kind, e.g. <dd> closes only the nearest dt or dd.
+var kindOf = map[string]string{
+	"dt":    "dd",
+	"th":    "td",
+	"thead": "tbody",
+	"tfoot": "tbody",
+	"rt":    "rb",
+	"rp":    "rb",
+	"rtc":   "rb",
+}
+
+// kind returns the name of the kind of the element named n
+func kind(n string) string {
+	if k, ok := kindOf[n]; ok {
+		return k
+	}
+
+	return n
+}
+
+// stopsImpliedEnd checks if the open element named n stops the search for the elements closed implicitly by the opening tag named m.
+func stopsImpliedEnd(n, m string) bool {
+	if tableCells[m] {
+		// the table cells, rows and sections are closed up to the nearest table
+		return n == "table" || n == "template" || n == "html"
+	}
+
+	return specialElements[n] && n != "address" && n != "div" && n != "p"
+}
+
+// hasOptionalEndTag checks if the closing tag of the element named n might be omitted
+func hasOptionalEndTag(n string) bool {
+	return closedByStartTag[n] != nil
+}
+
+// closableByStartTag maps the opening tags to the names of the elements which they might close implicitly.
+var closableByStartTag = reverse(closedByStartTag)
+
+// closableByEndTag maps the closing tags to the names of the elements which they might close implicitly.
+var closableByEndTag = reverse(closedByEndTag)
+
+// reverse returns the map of the tags to the names of the elements closed by them, from the map m of the elements to the sets of tags closing them
+func reverse(m map[string]map[string]bool) map[string][]string {
+	r := make(map[string][]string)
+	for n, tags := range m {
+		for t := range tags {
+			r[t] = append(r[t], n)
+		}
+	}
+
+	return r
+}
+
+// openElements is a stack of open elements, which finds the elements closed by the tags without scanning the whole stack.
+type openElements struct {
+	names  []string         // The names of the open elements, starting with the first opened one.
+	byName map[string][]int // The indexes of the open elements of each name, in ascending order.
+	scopes []int            // The indexes of the open elements which stop the search for the elements closed implicitly by an opening tag.
+	tables []int            // The indexes of the open elements which stop the search for the table cells, rows and sections.
+}
+
+// push adds the element named n on the top of the stack
+func (s *openElements) push(n string) {
+	if s.byName == nil {
+		s.byName = make(map[string][]int)
+	}
+
+	i := len(s.names)
+	s.names = append(s.names, n)
+	s.byName[n] = append(s.byName[n], i)
+	if stopsImpliedEnd(n, "") {
+		s.scopes = append(s.scopes, i)
+	}
+	if stopsImpliedEnd(n, "td") {
+		s.tables = append(s.tables, i)
+	}
+}
+
+// truncate removes the elements opened after the first open ones
+func (s *openElements) truncate(open int) {
+	for i := len(s.names) - 1; i >= open; i-- {
+		n := s.names[i]
+		s.byName[n] = s.byName[n][:len(s.byName[n])-1]
+	}
+	s.names = s.names[:open]
+	s.scopes = below(s.scopes, open)
+	s.tables = below(s.tables, open)
+}
+
+// below returns the indexes of the ascending list l, which are less than i
+func below(l []int, i int) []int {
+	for len(l) > 0 && l[len(l)-1] >= i {
+		l = l[:len(l)-1]
+	}
+
+	return l
+}
+
+// closeByStartTag returns the number of open elements, which stay open after the opening tag named m.
+func (s *openElements) closeByStartTag(m string) int {
+	// the search stops at the most recently opened element of the scope, which is closed only if it is of the same kind as m
+	stops := s.scopes
+	if tableCells[m] {
+		// the table cells, rows and sections are closed up to the nearest table
+		stops = s.tables
+	}
+	low := 0
+	if len(stops) > 0 {
+		low = stops[len(stops)-1]
+	}
+
+	// the indexes of the elements in the scope, which are closed by m
+	var closable []int
+	for _, n := range closableByStartTag[m] {
+		l := s.byName[n]
+		for j := len(l) - 1; j >= 0 && l[j] >= low; j-- {
+			closable = append(closable, l[j])
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(closable)))
+
+	// search from the most recently opened element
+	open := len(s.names)
+	for _, i := range closable {
+		// close the element and all elements opened after it
+		open = i
+
+		if kind(s.names[i]) == kind(m) {
+			// only the nearest element of the same kind is closed
+			break
+		}
+	}
+
+	return open
+}
+
+// closeByEndTag returns the number of open elements, which stay open after the closing tag named m.
+// explicit is true if the first closed element is closed by its own closing tag.
+func (s *openElements) closeByEndTag(m string) (open int, explicit bool) {
+	// the closing tag closes the most recently opened element with the same name and all elements opened after it
+	if l := s.byName[m]; len(l) > 0 {
+		return l[len(l)-1], true
+	}
+
+	// the closing tag of a parent closes the elements with an optional closing tag
+	open = len(s.names)
+	for _, n := range closableByEndTag[m] {
+		if l := s.byName[n]; len(l) > 0 && l[0] < open {
+			open = l[0]
+		}
+	}
+
+	return open, false
+}
+
+// blockElements is a set of elements which are displayed as blocks by default, so their text starts and ends on a new line.
+var blockElements = set(
+	"address", "article", "aside", "blockquote", "body", "caption", "center", "dd", "details", "dialog", "dir",
+	"div", "dl", "dt", "fieldset", "figcaption", "figure", "footer", "form", "frameset", "h1", "h2", "h3", "h4",
+	"h5", "h6", "header", "hgroup", "hr", "html", "legend", "li", "listing", "main", "menu", "nav", "ol",
+	"optgroup", "option", "p", "plaintext", "pre", "search", "section", "summary", "table", "tbody", "tfoot",
+	"thead", "tr", "ul", "xmp",
+)
+
+// preformattedElements is a set of elements whose white spaces are preserved.
+var preformattedElements = set("pre", "listing", "plaintext", "textarea", "xmp")
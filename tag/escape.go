@@ -0,0 +1,185 @@
+package tag
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// replacementTable maps the numeric character references in the range 0x80-0x9F to the characters of windows-1252.
+var replacementTable = [...]rune{
+	'€', // 0x80
+	'\u0081',
+	'‚',
+	'ƒ',
+	'„',
+	'…',
+	'†',
+	'‡',
+	'ˆ',
+	'‰',
+	'Š',
+	'‹',
+	'Œ',
+	'\u008D',
+	'Ž',
+	'\u008F',
+	'\u0090', // 0x90
+	'‘',
+	'’',
+	'“',
+	'”',
+	'•',
+	'–',
+	'—',
+	'˜',
+	'™',
+	'š',
+	'›',
+	'œ',
+	'\u009D',
+	'ž',
+	'Ÿ', // 0x9F
+}
+
+// UnescapeString returns s with all character references, e.g. &eacute; or &#8217;, replaced by the characters they represent.
+// It is useful for the text returned by Content, because attribute values in Tag.Attr are already unescaped.
+func UnescapeString(s string) string {
+	return unescape(s, false)
+}
+
+// unescape returns s with all character references replaced by the characters they represent.
+// If attribute is true, the special rule for the attribute values is applied:
+// a named reference without a semicolon is not replaced if it is followed by '=' or an alphanumeric character.
+//
+// Loosely inspired by the algorithm described here:
+// https://html.spec.whatwg.org/multipage/parsing.html#character-reference-state
+func unescape(s string, attribute bool) string {
+	i := strings.IndexByte(s, '&')
+	if i == -1 {
+		// there are no references
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+
+	for i != -1 {
+		// copy the text before the reference
+		b.WriteString(s[:i])
+		s = s[i:]
+
+		val, n := unescapeReference(s, attribute)
+		if n == 0 {
+			// the '&' is not a reference
+			b.WriteByte('&')
+			n = 1
+		} else {
+			b.WriteString(val)
+		}
+
+		s = s[n:]
+		i = strings.IndexByte(s, '&')
+	}
+
+	b.WriteString(s)
+
+	return b.String()
+}
+
+// unescapeReference returns the value of the character reference at the beginning of s and its length n.
+// If s does not start with a reference, n is 0.
+func unescapeReference(s string, attribute bool) (val string, n int) {
+	if len(s) > 1 && s[1] == '#' {
+		return unescapeNumeric(s)
+	}
+
+	// find the longest alphanumeric name, with a semicolon, if there is any
+	end := 1
+	for end < len(s) && end <= longestEntityName && isASCIIAlphanumeric(s[end]) {
+		end++
+	}
+	if end < len(s) && s[end] == ';' {
+		end++
+	}
+
+	// find the longest name which is a reference
+	for ; end > 1; end-- {
+		val, ok := entity[s[1:end]]
+		if !ok {
+			continue
+		}
+
+		if attribute && s[end-1] != ';' && end < len(s) && (s[end] == '=' || isASCIIAlphanumeric(s[end])) {
+			// for historical reasons, it is not a reference in an attribute value
+			return "", 0
+		}
+
+		return val, end
+	}
+
+	// no such reference
+	return "", 0
+}
+
+// unescapeNumeric returns the value of the numeric character reference at the beginning of s (e.g. &#233; or &#xE9;) and its length n.
+// If s does not start with a numeric reference, n is 0.
+func unescapeNumeric(s string) (val string, n int) {
+	// the reference starts with "&#" and is optionally followed by 'x' in the hexadecimal form
+	i := 2
+	base := rune(10)
+	if i < len(s) && (s[i] == 'x' || s[i] == 'X') {
+		base = 16
+		i++
+	}
+
+	// read the digits
+	start := i
+	var r rune
+	for ; i < len(s); i++ {
+		d := digitValue(s[i], base)
+		if d == -1 {
+			break
+		}
+
+		if r <= utf8.MaxRune {
+			r = r*base + d
+		}
+	}
+	if i == start {
+		// there are no digits
+		return "", 0
+	}
+
+	// the semicolon is optional
+	if i < len(s) && s[i] == ';' {
+		i++
+	}
+
+	switch {
+	case 0x80 <= r && r <= 0x9F:
+		r = replacementTable[r-0x80]
+	case r == 0 || r > utf8.MaxRune || (0xD800 <= r && r <= 0xDFFF):
+		r = utf8.RuneError
+	}
+
+	return string(r), i
+}
+
+// digitValue returns the value of the digit b in the base, or -1 if b is not a digit
+func digitValue(b byte, base rune) rune {
+	switch {
+	case '0' <= b && b <= '9':
+		return rune(b - '0')
+	case base == 16 && 'a' <= b && b <= 'f':
+		return rune(b-'a') + 10
+	case base == 16 && 'A' <= b && b <= 'F':
+		return rune(b-'A') + 10
+	}
+
+	return -1
+}
+
+// isASCIIAlphanumeric checks if b is an ASCII letter or digit
+func isASCIIAlphanumeric(b byte) bool {
+	return isASCIILetter(b) || '0' <= b && b <= '9'
+}
@@ -0,0 +1,30 @@
+package tag
+
+import "testing"
+
+func TestUnescape(t *testing.T) {
+	tests := []struct {
+		name      string
+		s         string
+		attribute bool
+		want      string
+	}{
+		{"1", "no references", false, "no references"},
+		{"2", "caf&eacute; &amp; bar", false, "café & bar"},
+		{"3", "&#8217;&#x2019;&#X2019", false, "’’’"},
+		{"4", "&#128;&#0;&#xD800;&#x110000;", false, "€���"},
+		{"5", "&notit; &notin;", false, "¬it; ∉"},
+		{"6", "&amp &ampx &unknown; & &# &#x;", false, "& &x &unknown; & &# &#x;"},
+		{"7", "?a=1&amp;b=2&copy=3&copy", true, "?a=1&b=2&copy=3©"},
+		{"8", "&copy=3&notit;", false, "©=3¬it;"},
+		{"9", "&NotEqualTilde;&nLt;", false, "≂̸≪⃒"},
+		{"10", "&#99999999999999999999;", false, "�"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unescape(tt.s, tt.attribute); got != tt.want {
+				t.Errorf("unescape() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package tag_test
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/kmatulewicz/go-html/tag"
+)
+
+func ExampleTokenizer() {
+	// The document can be read from any io.Reader, e.g. http.Response.Body.
+	z := tag.NewTokenizer(strings.NewReader(doc))
+
+	for {
+		tok, err := z.Next()
+		if err == io.EOF {
+			// the end of the document
+			break
+		}
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		// Print the addresses of all links.
+		if tok.Type == tag.StartTagToken && tok.Name == "a" {
+			fmt.Println(tok.Start, tok.Attr["href"])
+		}
+	}
+
+	// Output:
+	// 44 https://example.com/1
+	// 90 https://example.com/2
+	// 136 https://example.com/3
+	// 182 https://example.com/4
+	// 254 https://notinteresting.com/1
+}
@@ -0,0 +1,428 @@
+package tag
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The encoding types of the form data.
+const (
+	URLEncoded = "application/x-www-form-urlencoded"
+	Multipart  = "multipart/form-data"
+	TextPlain  = "text/plain"
+)
+
+// inputTypes is a set of the valid types of input; the other types are treated as text.
+var inputTypes = set(
+	"hidden", "text", "search", "tel", "url", "email", "password", "date", "month", "week", "time", "datetime-local",
+	"number", "range", "color", "checkbox", "radio", "file", "submit", "image", "reset", "button",
+)
+
+// FormModel is a model of an HTML form returned by Form.
+type FormModel struct {
+	Action  string   // The action attribute as it is written in the document, without white spaces; empty if the form is submitted to the document's URL.
+	Method  string   // The method used to submit the form: "GET" or "POST".
+	Enctype string   // The encoding type of the form data: URLEncoded, Multipart or TextPlain. It is used only by POST.
+	Fields  []Field  // The input, select, textarea and button elements of the form, in the document order.
+	BaseURL *url.URL // The URL of the document used to resolve Action by Request; it can be set by the caller.
+	Tag     *Tag     // The form tag.
+}
+
+// Field is an input, select, textarea or button element of a form.
+type Field struct {
+	Name     string   // The name attribute.
+	Type     string   // The lowercase type of input or button (e.g. "text", "checkbox", "radio" or "submit"), "select" or "textarea".
+	Value    string   // The value of input or textarea. For checkbox and radio, the value attribute or "on" if it is missing.
+	Checked  bool     // True if the checkbox or radio button is checked. Only the last checked radio button of a group is checked.
+	Disabled bool     // True if the element or its fieldset is disabled. The disabled fields are not submitted.
+	Multiple bool     // True if more than one option of select can be selected.
+	Options  []Option // The options of select.
+	Tag      *Tag     // The tag of the element.
+}
+
+// Option is an option of a select element.
+type Option struct {
+	Value    string // The value attribute, or the text of the option if it is missing.
+	Text     string // The text of the option, with white spaces collapsed.
+	Selected bool   // True if the option is selected, as a browser would select it by default.
+	Disabled bool   // True if the option or its optgroup is disabled.
+}
+
+// formEntry is a name and value submitted by a form.
+type formEntry struct {
+	name  string
+	value string
+	file  bool // True for the file input; the value is the file name.
+}
+
+// Form returns the model of the form t with its fields and their default values.
+// The fields are the elements inside t and the ones associated with it by the form attribute.
+// Returns nil if t is not a form.
+func Form(t *Tag) *FormModel {
+	if t == nil || t.Name != "form" {
+		return nil
+	}
+
+	n := t.node()
+	if n == nil {
+		return nil
+	}
+
+	f := &FormModel{Action: trimSpace(t.Attr["action"]), Method: "GET", Enctype: URLEncoded, Tag: t}
+	if toLowerASCII(t.Attr["method"]) == "post" {
+		f.Method = "POST"
+	}
+	switch e := toLowerASCII(t.Attr["enctype"]); e {
+	case Multipart, TextPlain:
+		f.Enctype = e
+	}
+
+	// the last checked radio button of each group
+	radios := make(map[string]int)
+
+	id := t.Attr["id"]
+	for e := n.doc.Root.FirstChild; e != nil; e = nextInOrder(e, n.doc.Root) {
+		if e.Type != ElementNode || !formOwner(e, n, id) || hasAncestorNamed(e, "datalist") {
+			continue
+		}
+
+		field := Field{Name: e.Attr["name"], Tag: e.Tag(), Disabled: isDisabled(e)}
+		switch e.Name {
+		case "input":
+			field.Type = toLowerASCII(trimSpace(e.Attr["type"]))
+			if !inputTypes[field.Type] {
+				field.Type = "text"
+			}
+
+			field.Value = e.Attr["value"]
+			if field.Type == "checkbox" || field.Type == "radio" {
+				_, field.Checked = e.Attr["checked"]
+				if _, ok := e.Attr["value"]; !ok {
+					field.Value = "on"
+				}
+			}
+
+			if field.Type == "radio" && field.Checked && field.Name != "" {
+				// a checked radio button unchecks the previous one in the group
+				if i, ok := radios[field.Name]; ok {
+					f.Fields[i].Checked = false
+				}
+				radios[field.Name] = len(f.Fields)
+			}
+		case "button":
+			field.Type = toLowerASCII(trimSpace(e.Attr["type"]))
+			if field.Type != "reset" && field.Type != "button" {
+				field.Type = "submit"
+			}
+			field.Value = e.Attr["value"]
+		case "select":
+			field.Type = "select"
+			_, field.Multiple = e.Attr["multiple"]
+			field.Options = selectOptions(e, field.Multiple)
+		case "textarea":
+			field.Type = "textarea"
+			// the new line at the beginning of textarea is ignored
+			field.Value = strings.TrimPrefix(unescape(field.Tag.Content(), false), "\n")
+		default:
+			continue
+		}
+
+		f.Fields = append(f.Fields, field)
+	}
+
+	return f
+}
+
+// formOwner checks if the form element is the owner of the element e; id is the id of the form
+func formOwner(e, form *Node, id string) bool {
+	if v, ok := e.Attr["form"]; ok {
+		// the element is associated with the form of the given id
+		return id != "" && v == id
+	}
+
+	for p := e.Parent; p != nil; p = p.Parent {
+		if p == form {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasAncestorNamed checks if e is inside an element of the given name
+func hasAncestorNamed(e *Node, name string) bool {
+	for p := parentElement(e); p != nil; p = parentElement(p) {
+		if p.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isDisabled checks if e is disabled by its attribute or by a fieldset, unless it is inside the first legend of the fieldset
+func isDisabled(e *Node) bool {
+	if _, ok := e.Attr["disabled"]; ok {
+		return true
+	}
+
+	child := e
+	for p := parentElement(e); p != nil; child, p = p, parentElement(p) {
+		if _, ok := p.Attr["disabled"]; !ok || p.Name != "fieldset" {
+			continue
+		}
+
+		// find the first legend of the fieldset
+		legend := p.FirstChild
+		for legend != nil && (legend.Type != ElementNode || legend.Name != "legend") {
+			legend = legend.NextSibling
+		}
+		if legend == nil || child != legend {
+			return true
+		}
+	}
+
+	return false
+}
+
+// selectOptions returns the options of the select element e, with the options selected as a browser would select them
+func selectOptions(e *Node, multiple bool) []Option {
+	var options []Option
+	var selected []int
+
+	for o := e.FirstChild; o != nil; o = nextInOrder(o, e) {
+		if o.Type != ElementNode || o.Name != "option" {
+			continue
+		}
+
+		text := collapseSpace(o.Tag().Text())
+		opt := Option{Value: text, Text: text}
+		if v, ok := o.Attr["value"]; ok {
+			opt.Value = v
+		}
+		if _, ok := o.Attr["selected"]; ok {
+			opt.Selected = true
+			selected = append(selected, len(options))
+		}
+		_, opt.Disabled = o.Attr["disabled"]
+		if p := parentElement(o); p != nil && p.Name == "optgroup" {
+			if _, ok := p.Attr["disabled"]; ok {
+				opt.Disabled = true
+			}
+		}
+
+		options = append(options, opt)
+	}
+
+	if multiple {
+		return options
+	}
+
+	// only one option of select can be selected
+	if len(selected) > 1 {
+		for _, i := range selected[:len(selected)-1] {
+			options[i].Selected = false
+		}
+	}
+
+	// the first option is selected by default in a drop-down list
+	size := 1
+	if v, err := strconv.Atoi(trimSpace(e.Attr["size"])); err == nil && v > 0 {
+		size = v
+	}
+	if len(selected) == 0 && size == 1 {
+		for i := range options {
+			if !options[i].Disabled {
+				options[i].Selected = true
+				break
+			}
+		}
+	}
+
+	return options
+}
+
+// Values returns the names and values which would be submitted by the form, without a submit button.
+// As in a browser, the disabled fields, the fields without a name, the unchecked checkboxes and radio buttons,
+// and the buttons are omitted, and the values of file inputs are empty.
+func (f *FormModel) Values() url.Values {
+	values := make(url.Values)
+	for _, e := range f.entries() {
+		values.Add(e.name, e.value)
+	}
+
+	return values
+}
+
+// entries returns the entries submitted by the form, in the document order
+func (f *FormModel) entries() []formEntry {
+	var entries []formEntry
+	for _, field := range f.Fields {
+		if field.Disabled || field.Name == "" {
+			continue
+		}
+
+		switch field.Type {
+		case "submit", "image", "reset", "button":
+			// only the button which submits the form is submitted
+		case "checkbox", "radio":
+			if field.Checked {
+				entries = append(entries, formEntry{name: field.Name, value: field.Value})
+			}
+		case "file":
+			entries = append(entries, formEntry{name: field.Name, file: true})
+		case "select":
+			for _, o := range field.Options {
+				if o.Selected && !o.Disabled {
+					entries = append(entries, formEntry{name: field.Name, value: o.Value})
+				}
+			}
+		case "textarea":
+			// the new lines are submitted as CRLF
+			v := strings.ReplaceAll(strings.ReplaceAll(field.Value, "\r\n", "\n"), "\n", "\r\n")
+			entries = append(entries, formEntry{name: field.Name, value: v})
+		default:
+			v := field.Value
+			if field.Type == "hidden" && field.Name == "_charset_" {
+				v = "UTF-8"
+			}
+			entries = append(entries, formEntry{name: field.Name, value: v})
+		}
+	}
+
+	return entries
+}
+
+// Request returns an *http.Request which submits the form with the values returned by Values, as a browser would send it.
+// The values of the names present in overrides replace the values of the form at the position of the first field with the name;
+// a name without values is not submitted, and the names which are not in the form are added at the end.
+// Action is resolved against the <base> of the document and BaseURL; an empty Action submits the form to BaseURL.
+// The error is returned if the resolved URL is not absolute, e.g. Action is relative and BaseURL is nil.
+// The values are sent in the query of a GET request, or in the body of a POST request encoded according to Enctype.
+func (f *FormModel) Request(overrides url.Values) (*http.Request, error) {
+	// replace the values of the overridden names
+	var entries []formEntry
+	replaced := make(map[string]bool)
+	for _, e := range f.entries() {
+		values, ok := overrides[e.name]
+		if !ok {
+			entries = append(entries, e)
+			continue
+		}
+
+		if !replaced[e.name] {
+			replaced[e.name] = true
+			for _, v := range values {
+				entries = append(entries, formEntry{name: e.name, value: v})
+			}
+		}
+	}
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		if !replaced[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, v := range overrides[name] {
+			entries = append(entries, formEntry{name: name, value: v})
+		}
+	}
+
+	u, err := f.actionURL()
+	if err != nil {
+		return nil, err
+	}
+
+	if f.Method != "POST" {
+		u.RawQuery = encodeEntries(entries)
+		return http.NewRequest(http.MethodGet, u.String(), nil)
+	}
+
+	var body bytes.Buffer
+	contentType := f.Enctype
+	switch f.Enctype {
+	case Multipart:
+		w := multipart.NewWriter(&body)
+		for _, e := range entries {
+			if e.file {
+				// no file is selected
+				_, err = w.CreateFormFile(e.name, e.value)
+			} else {
+				var part io.Writer
+				if part, err = w.CreateFormField(e.name); err == nil {
+					_, err = io.WriteString(part, e.value)
+				}
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		contentType = w.FormDataContentType()
+	case TextPlain:
+		for _, e := range entries {
+			body.WriteString(e.name + "=" + e.value + "\r\n")
+		}
+	default:
+		contentType = URLEncoded
+		body.WriteString(encodeEntries(entries))
+	}
+
+	req, err := http.NewRequest(http.MethodPost, u.String(), &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	return req, nil
+}
+
+// actionURL returns the absolute URL to which the form is submitted
+func (f *FormModel) actionURL() (*url.URL, error) {
+	u := f.BaseURL
+	if f.Action != "" {
+		base := f.BaseURL
+		if f.Tag != nil {
+			base = documentBase(f.Tag.doc, f.BaseURL)
+		}
+
+		var err error
+		if u, err = resolveURL(base, f.Action); err != nil {
+			return nil, err
+		}
+	}
+
+	if u == nil || !u.IsAbs() {
+		return nil, fmt.Errorf("tag: the form action %q is not an absolute URL", f.Action)
+	}
+
+	// the query of the copy is replaced by GET
+	c := *u
+	return &c, nil
+}
+
+// encodeEntries returns the entries in the application/x-www-form-urlencoded format, keeping their order
+func encodeEntries(entries []formEntry) string {
+	var b strings.Builder
+	for i, e := range entries {
+		if i > 0 {
+			b.WriteByte('&')
+		}
+		b.WriteString(url.QueryEscape(e.name))
+		b.WriteByte('=')
+		b.WriteString(url.QueryEscape(e.value))
+	}
+
+	return b.String()
+}
@@ -0,0 +1,179 @@
+package tag
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const formDoc = `<base href="https://example.com/app/">
+<form id=f action=" search?x=1#top " method=post>
+	<input name=q value="go html">
+	<input name=page type=hidden value=2>
+	<input name=_charset_ type=hidden>
+	<input name=token type=HIDDEN value=a&amp;b>
+	<input value=no-name>
+	<input name=kind type=unknown value=t>
+	<input name=agree type=checkbox checked>
+	<input name=news type=checkbox value=yes>
+	<input name=size type=radio value=s checked>
+	<input name=size type=radio value=m checked>
+	<input name=size type=radio value=l>
+	<input name=off disabled value=1>
+	<fieldset disabled><legend><input name=in-legend value=1></legend><input name=in-fieldset value=1></fieldset>
+	<select name=lang><option>  Go  <option value=rs selected>Rust<option value=py selected>Python</select>
+	<select name=os><option disabled>Pick<optgroup label=x><option value=linux>Linux</optgroup></select>
+	<select name=tags multiple><option selected>a<option>b<option selected disabled>c</select>
+	<select name=big size=3><option>x</select>
+	<textarea name=msg>
+line 1
+line &lt;2&gt;</textarea>
+	<datalist><input name=hidden-in-datalist value=1></datalist>
+	<input name=upload type=file>
+	<input name=go type=submit value=Go><button name=b>B</button>
+</form>
+<input name=outside value=1 form=f><input name=other value=1 form=g><input name=nowhere value=1>`
+
+func TestForm(t *testing.T) {
+	f := Form(Find(formDoc, "form", nil))
+	if f == nil {
+		t.Fatalf("Form() = nil")
+	}
+
+	if f.Action != "search?x=1#top" || f.Method != "POST" || f.Enctype != URLEncoded {
+		t.Errorf("Form() = %q %q %q", f.Action, f.Method, f.Enctype)
+	}
+
+	want := url.Values{
+		"q":         {"go html"},
+		"page":      {"2"},
+		"_charset_": {"UTF-8"},
+		"token":     {"a&b"},
+		"kind":      {"t"},
+		"agree":     {"on"},
+		"size":      {"m"},
+		"in-legend": {"1"},
+		"lang":      {"py"},
+		"os":        {"linux"},
+		"tags":      {"a"},
+		"msg":       {"line 1\r\nline <2>"},
+		"upload":    {""},
+		"outside":   {"1"},
+	}
+	if got := f.Values(); !reflect.DeepEqual(got, want) {
+		t.Errorf("FormModel.Values() = %v, want %v", got, want)
+	}
+
+	// the fields
+	var names []string
+	for _, field := range f.Fields {
+		names = append(names, field.Name+":"+field.Type)
+	}
+	wantNames := "q:text page:hidden _charset_:hidden token:hidden :text kind:text agree:checkbox news:checkbox size:radio size:radio size:radio " +
+		"off:text in-legend:text in-fieldset:text lang:select os:select tags:select big:select msg:textarea upload:file go:submit b:submit outside:text"
+	if got := strings.Join(names, " "); got != wantNames {
+		t.Errorf("FormModel.Fields = %q, want %q", got, wantNames)
+	}
+
+	lang := f.Fields[14]
+	wantOptions := []Option{{Value: "Go", Text: "Go"}, {Value: "rs", Text: "Rust"}, {Value: "py", Text: "Python", Selected: true}}
+	if !reflect.DeepEqual(lang.Options, wantOptions) {
+		t.Errorf("FormModel.Fields[lang].Options = %v, want %v", lang.Options, wantOptions)
+	}
+	if big := f.Fields[17]; big.Options[0].Selected {
+		t.Errorf("FormModel.Fields[big].Options = %v, want none selected", big.Options)
+	}
+	if f.Fields[8].Checked || !f.Fields[9].Checked || !f.Fields[11].Disabled || !f.Fields[13].Disabled || f.Fields[12].Disabled {
+		t.Errorf("FormModel.Fields = %v", f.Fields)
+	}
+
+	if Form(Find(formDoc, "input", nil)) != nil {
+		t.Errorf("Form() of input is not nil")
+	}
+}
+
+func TestFormModel_Request(t *testing.T) {
+	doc := `<form action="/find" method=GET><input name=q value=a><input name=page value=1><input name=x value=y></form>`
+	page, _ := url.Parse("https://example.com/dir/page?old=1")
+	f := Form(Find(doc, "form", nil))
+	f.BaseURL = page
+
+	req, err := f.Request(url.Values{"page": {"3"}, "x": nil})
+	if err != nil {
+		t.Fatalf("FormModel.Request() error = %v", err)
+	}
+	if req.Method != "GET" || req.URL.String() != "https://example.com/find?q=a&page=3" {
+		t.Errorf("FormModel.Request() = %s %s", req.Method, req.URL)
+	}
+
+	// the overridden values keep the position of the fields, and the other names are added at the end
+	req, _ = f.Request(url.Values{"q": {"z", "w"}, "b": {"2"}, "a": {"1"}})
+	if got, want := req.URL.RawQuery, "q=z&q=w&page=1&x=y&a=1&b=2"; got != want {
+		t.Errorf("FormModel.Request() query = %q, want %q", got, want)
+	}
+
+	// an empty action submits the form to the URL of the document, not to <base>
+	f = Form(Find(`<base href="https://cdn.example.com/static/"><form><input name=q value=1></form>`, "form", nil))
+	f.BaseURL = page
+	req, err = f.Request(nil)
+	if err != nil || req.URL.String() != "https://example.com/dir/page?q=1" || page.RawQuery != "old=1" {
+		t.Errorf("FormModel.Request() = %v, %v, want %s", req.URL, err, "https://example.com/dir/page?q=1")
+	}
+
+	// the URL of the request must be absolute
+	for _, doc := range []string{`<form action="/x"><input name=q></form>`, `<form><input name=q></form>`} {
+		if req, err := Form(Find(doc, "form", nil)).Request(nil); err == nil {
+			t.Errorf("FormModel.Request() = %v, want an error for %s", req.URL, doc)
+		}
+	}
+
+	// POST with the base of the document
+	f = Form(Find(formDoc, "form", nil))
+	req, err = f.Request(nil)
+	if err != nil {
+		t.Fatalf("FormModel.Request() error = %v", err)
+	}
+	body, _ := io.ReadAll(req.Body)
+	if req.Method != "POST" || req.URL.String() != "https://example.com/app/search?x=1#top" || req.Header.Get("Content-Type") != URLEncoded {
+		t.Errorf("FormModel.Request() = %s %s %s", req.Method, req.URL, req.Header.Get("Content-Type"))
+	}
+	wantBody := "q=go+html&page=2&_charset_=UTF-8&token=a%26b&kind=t&agree=on&size=m&in-legend=1&lang=py&os=linux&tags=a&msg=line+1%0D%0Aline+%3C2%3E&upload=&outside=1"
+	if string(body) != wantBody {
+		t.Errorf("FormModel.Request() body = %q, want %q", body, wantBody)
+	}
+
+	// text/plain
+	f = Form(Find(`<form method=post enctype=TEXT/PLAIN><input name=a value="1 2"><input name=b value=3></form>`, "form", nil))
+	f.BaseURL = page
+	req, _ = f.Request(nil)
+	body, _ = io.ReadAll(req.Body)
+	if string(body) != "a=1 2\r\nb=3\r\n" || req.Header.Get("Content-Type") != TextPlain {
+		t.Errorf("FormModel.Request() body = %q", body)
+	}
+
+	// multipart/form-data
+	f = Form(Find(`<form method=post enctype=multipart/form-data><input name=a value=1><input type=file name=f></form>`, "form", nil))
+	f.BaseURL = page
+	req, _ = f.Request(url.Values{"c": {"2"}})
+	_, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("FormModel.Request() Content-Type error = %v", err)
+	}
+	r := multipart.NewReader(req.Body, params["boundary"])
+	var parts []string
+	for {
+		p, err := r.NextPart()
+		if err != nil {
+			break
+		}
+		v, _ := io.ReadAll(p)
+		parts = append(parts, p.FormName()+"="+string(v)+";"+p.FileName())
+	}
+	if got := strings.Join(parts, " "); got != "a=1; f=; c=2;" {
+		t.Errorf("FormModel.Request() parts = %q", got)
+	}
+}
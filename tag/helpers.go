@@ -2,105 +2,122 @@ package tag
 
 import (
 	"strings"
-	"unicode"
+	"unicode/utf8"
 )
 
 // getAfterClosureIndex returns the index of the next character after the closing tag's end
-// for the tag named n of the document doc, starting at the index i.
+// for the tag named n of the document doc, starting at the index i. n must be lowercase.
+// If the closing tag is omitted and the element is closed implicitly, e.g. li by the next <li>, it returns the index where the element ends.
 // Returns -1 if there is no closing tag.
 func getAfterClosureIndex(doc, n string, i int) int {
-	// number of closing tags to be found
-	count := 1
+	// the stack of open elements, starting with the tag itself
+	var open openElements
+	open.push(n)
 	// start at the beginning of a content
-	pos := i
+	z := newTokenizer(doc, i, n)
 
-	// until some closing tags are left to be found
-	for count > 0 {
-		// check if the position is within the bounds of doc
-		if pos >= len(doc)-1 {
-			return -1
+	// until the tag is closed
+	for {
+		tok, _, err := z.next()
+		if err != nil {
+			break
 		}
 
-		// find the next closure tag
-		_, closure := findEndTag(doc[pos:], n)
-		if closure == -1 {
-			// there is no closure
-			return -1
-		}
+		switch tok.Type {
+		case StartTagToken:
+			// close the elements which are closed implicitly by the tag
+			open.truncate(open.closeByStartTag(tok.Name))
+			if len(open.names) == 0 {
+				// the element ends where the tag starts
+				return tok.Start
+			}
 
-		// find the next tag with the same name
-		_, next := findStartTag(doc[pos:], n)
-
-		// check if there is no next tag with the same name, or if it is after the closure tag
-		if next == -1 || closure < next {
-			// decrease the number of closing tags to be found
-			count--
-			// set the position after the beginning of the closing tag.
-			pos += closure
-		} else {
-			// if the next tag with the same name is before the closing tag, increase the number of closing tags to be found
-			count++
-			// and set the position at the beginning of the next tag with the same name
-			pos += next
+			if !closedByOpeningTag(tok.Name, tok.SelfClosing) {
+				open.push(tok.Name)
+			}
+		case EndTagToken:
+			// close the elements which are closed by the tag
+			o, explicit := open.closeByEndTag(tok.Name)
+			open.truncate(o)
+			if len(open.names) == 0 && explicit {
+				// return the index of the next character after the closing tag's end
+				return tok.End
+			}
+			if len(open.names) == 0 {
+				// the element ends where the closing tag of its parent starts
+				return tok.Start
+			}
 		}
 	}
 
-	// return the index of the next character after the closing tag's end
-	return pos
+	if hasOptionalEndTag(n) {
+		// the element lasts until the end of the document
+		return len(doc)
+	}
+
+	// there is no closure
+	return -1
 }
 
-// findTag returns the start and end points of the tag with the name n (n includes the tag's opening sequence) in s
-func findTag(s, n string) (start, end int) {
-	// start at the beginning of s
-	pos := 0
-	// until reached the end of s
-	for pos < len(s)-1 {
-		// localize the beginning of a tag, starting from the position pos
-		start = strings.Index(s[pos:], n)
-		if start == -1 {
-			// there is no tag with n name in s after pos
-			return -1, -1
-		}
-		// set the start point in relation to s
-		start = start + pos
-
-		// localize the nearest closure of a found tag
-		end = strings.Index(s[start:], ">")
-		if end == -1 {
-			// there are no closures in s after pos
-			return -1, -1
-		}
-		// set the end point in relation to s
-		end = start + end + 1
-
-		// calculate the position of the next char after the found tag name
-		endNameCharIndex := start + len(n)
-		// check if the found tag name is not only the beginning of the proper name (after the name is some other character, then a closure or a white space)
-		if isValidAttrNameChar(s[endNameCharIndex]) {
-			// continue after the current tag
-			pos = end
-			continue
-		}
+// closingTagIndex returns the index of the closing tag's beginning of the element named n, which ends at the index end of doc.
+// Returns end if the closing tag was omitted.
+func closingTagIndex(doc, n string, end int) int {
+	i := strings.LastIndex(doc[:end], "</")
+	if i == -1 {
+		return end
+	}
 
-		// return the positions
-		return start, end
+	tok, _, l := lex(doc[i:end], true)
+	if tok.Type != EndTagToken || tok.Name != n || i+l != end {
+		// the element is closed implicitly
+		return end
 	}
 
-	// no such tag
-	return -1, -1
+	return i
 }
 
-// findStartTag returns the start and end points of the opening tag with the name n in s
-func findStartTag(s, n string) (start, end int) {
-	return findTag(s, "<"+n)
+// isValidAttrNameChar checks if b is a valid character for an attribute name
+func isValidAttrNameChar(b byte) bool {
+	return !(isSpace(b) || b == '>' || b == '/')
 }
 
-// findStartTag returns the start and end points of the closing tag with the name n in s
-func findEndTag(s, n string) (start, end int) {
-	return findTag(s, "</"+n)
+// isSpace checks if b is an ASCII white space as defined by the HTML specification
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\f' || b == '\r'
 }
 
-// isValidAttrNameChar checks if b is a valid character for an attribute name
-func isValidAttrNameChar(b byte) bool {
-	return !(unicode.IsSpace(rune(b)) || b == '>' || b == '/')
+// hasField checks if the list s of tokens separated by ASCII white spaces contains the token f
+func hasField(s, f string) bool {
+	for len(s) > 0 {
+		// skip the white spaces
+		i := 0
+		for i < len(s) && isSpace(s[i]) {
+			i++
+		}
+		s = s[i:]
+
+		// read the token
+		i = 0
+		for i < len(s) && !isSpace(s[i]) {
+			i++
+		}
+		if i > 0 && s[:i] == f {
+			return true
+		}
+		s = s[i:]
+	}
+
+	return false
+}
+
+// splitSpace returns the tokens of s separated by ASCII white spaces
+func splitSpace(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r < utf8.RuneSelf && isSpace(byte(r))
+	})
+}
+
+// trimSpace returns s without leading and trailing ASCII white spaces
+func trimSpace(s string) string {
+	return strings.Trim(s, " \t\n\f\r")
 }
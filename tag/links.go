@@ -0,0 +1,125 @@
+package tag
+
+import "net/url"
+
+// LinkKind is a kind of link returned by Links. The kinds can be combined with |, e.g. LinkAnchor | LinkArea.
+type LinkKind uint
+
+const (
+	LinkAnchor     LinkKind = 1 << iota // The href of <a>.
+	LinkArea                            // The href of <area> in an image map.
+	LinkLink                            // The href of <link>, e.g. a stylesheet or an alternate version of the page.
+	LinkImage                           // The src of <img>.
+	LinkScript                          // The src of <script>.
+	LinkFrame                           // The src of <iframe>.
+	LinkFormAction                      // The action of <form>.
+
+	AllLinks = LinkAnchor | LinkArea | LinkLink | LinkImage | LinkScript | LinkFrame | LinkFormAction // All kinds of links.
+)
+
+// linkAttr maps the names of the elements to the kinds of their links and the attributes containing the URLs.
+var linkAttr = map[string]struct {
+	kind LinkKind
+	attr string
+}{
+	"a":      {LinkAnchor, "href"},
+	"area":   {LinkArea, "href"},
+	"link":   {LinkLink, "href"},
+	"img":    {LinkImage, "src"},
+	"script": {LinkScript, "src"},
+	"iframe": {LinkFrame, "src"},
+	"form":   {LinkFormAction, "action"},
+}
+
+// Link is a link found in a document by Links.
+type Link struct {
+	URL  *url.URL // The absolute URL, resolved against the base URL of the document.
+	Text string   // The text of <a> as in TextEquals, or the alt attribute of <area> and <img>. Empty for other kinds.
+	Rel  []string // The lowercase tokens of the rel attribute of <a>, <area> and <link>, e.g. nofollow.
+	Kind LinkKind // The kind of the link.
+	Tag  *Tag     // The tag containing the link.
+}
+
+// Links returns the links of the given kinds found in the document s, in the document order; if no kinds are given, all links are returned.
+// The URLs are resolved against the href of the first <base> element, which is itself resolved against pageURL.
+// pageURL is the URL of the document; if it is nil, relative URLs are resolved only against <base>.
+// The values which are not valid URLs are skipped.
+func Links(s string, pageURL *url.URL, kinds ...LinkKind) []Link {
+	var kind LinkKind
+	for _, k := range kinds {
+		kind |= k
+	}
+	if kind == 0 {
+		kind = AllLinks
+	}
+
+	base := documentBase(s, pageURL)
+
+	var names []string
+	for name, l := range linkAttr {
+		if kind&l.kind != 0 {
+			names = append(names, name)
+		}
+	}
+
+	var links []Link
+	for t := FindAny(s, names, nil); t != nil; t = t.Next() {
+		l := linkAttr[t.Name]
+		v, ok := t.Attr[l.attr]
+		if !ok {
+			continue
+		}
+
+		u, err := resolveURL(base, v)
+		if err != nil {
+			// not a valid URL
+			continue
+		}
+
+		link := Link{URL: u, Kind: l.kind, Tag: t}
+		switch t.Name {
+		case "a":
+			link.Text = t.text()
+		case "area", "img":
+			link.Text = t.Attr["alt"]
+		}
+		if t.Name == "a" || t.Name == "area" || t.Name == "link" {
+			link.Rel = splitSpace(toLowerASCII(t.Attr["rel"]))
+		}
+
+		links = append(links, link)
+	}
+
+	return links
+}
+
+// documentBase returns the base URL of the document s, i.e. the href of the first <base> resolved against pageURL, or pageURL
+func documentBase(s string, pageURL *url.URL) *url.URL {
+	b := Find(s, "base", []Check{Has("href")})
+	if b == nil {
+		return pageURL
+	}
+
+	u, err := resolveURL(pageURL, b.Attr["href"])
+	if err != nil {
+		// the base is not a valid URL
+		return pageURL
+	}
+
+	return u
+}
+
+// resolveURL returns the URL of the attribute value v resolved against base, which might be nil
+func resolveURL(base *url.URL, v string) (*url.URL, error) {
+	// the leading and trailing white spaces are not a part of the URL
+	u, err := url.Parse(trimSpace(v))
+	if err != nil {
+		return nil, err
+	}
+
+	if base == nil {
+		return u, nil
+	}
+
+	return base.ResolveReference(u), nil
+}
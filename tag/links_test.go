@@ -0,0 +1,91 @@
+package tag
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestLinks(t *testing.T) {
+	doc := `<html><head><base href="/docs/"><link rel="Stylesheet" href="../s.css"><script src=//cdn.example.com/a.js></script><script>var a = "<a href=/x>";</script></head>
+<body><a href="../page" rel="nofollow  noopener">Previous
+ <b>page</b></a><a name=top>no href</a><a href=" ?p=2 ">2</a><!-- <a href=/c> --><a href="#top">Top</a>
+<img src="i.png" alt="Logo"><img alt=none><map><area href="https://example.org/" alt="Other" rel=external></map>
+<iframe src="frame.html"></iframe><form action="/search"><input name=q></form><form><a href="http://[::1">bad</a><a href="mailto:x@example.com">Mail</a></body></html>`
+
+	page, _ := url.Parse("https://example.com/site/index.html?x=1")
+
+	tests := []struct {
+		name  string
+		page  *url.URL
+		kinds []LinkKind
+		want  []string
+	}{
+		{
+			"all",
+			page,
+			nil,
+			[]string{
+				"LinkLink https://example.com/s.css [stylesheet] <link>",
+				"LinkScript https://cdn.example.com/a.js [] <script>",
+				"LinkAnchor https://example.com/page [nofollow noopener] <a> Previous page",
+				"LinkAnchor https://example.com/docs/?p=2 [] <a> 2",
+				"LinkAnchor https://example.com/docs/#top [] <a> Top",
+				"LinkImage https://example.com/docs/i.png [] <img> Logo",
+				"LinkArea https://example.org/ [external] <area> Other",
+				"LinkFrame https://example.com/docs/frame.html [] <iframe>",
+				"LinkFormAction https://example.com/search [] <form>",
+				"LinkAnchor mailto:x@example.com [] <a> Mail",
+			},
+		},
+		{
+			"anchors and areas",
+			page,
+			[]LinkKind{LinkAnchor, LinkArea},
+			[]string{
+				"LinkAnchor https://example.com/page [nofollow noopener] <a> Previous page",
+				"LinkAnchor https://example.com/docs/?p=2 [] <a> 2",
+				"LinkAnchor https://example.com/docs/#top [] <a> Top",
+				"LinkArea https://example.org/ [external] <area> Other",
+				"LinkAnchor mailto:x@example.com [] <a> Mail",
+			},
+		},
+		{
+			"scripts without page",
+			nil,
+			[]LinkKind{LinkScript | LinkImage},
+			[]string{
+				"LinkScript //cdn.example.com/a.js [] <script>",
+				"LinkImage /docs/i.png [] <img> Logo",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, l := range Links(doc, tt.page, tt.kinds...) {
+				s := fmt.Sprintf("%s %s %v <%s>", kindName(l.Kind), l.URL, l.Rel, l.Tag.Name)
+				if l.Text != "" {
+					s += " " + l.Text
+				}
+				got = append(got, s)
+			}
+
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Links() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+
+	// without <base>, the URLs are resolved against the page
+	links := Links(`<a href="../up">x</a><base target=_blank>`, page)
+	if len(links) != 1 || links[0].URL.String() != "https://example.com/up" {
+		t.Errorf("Links() = %v, want https://example.com/up", links)
+	}
+}
+
+// kindName returns the name of the link kind k
+func kindName(k LinkKind) string {
+	return map[LinkKind]string{LinkAnchor: "LinkAnchor", LinkArea: "LinkArea", LinkLink: "LinkLink", LinkImage: "LinkImage", LinkScript: "LinkScript", LinkFrame: "LinkFrame", LinkFormAction: "LinkFormAction"}[k]
+}
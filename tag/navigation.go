@@ -0,0 +1,104 @@
+package tag
+
+// document returns the tree of t's document, which is parsed when it is needed for the first time
+func (t *Tag) document() *Document {
+	if t.tree == nil {
+		// the tag was not found in a document, e.g. it is a literal
+		t.tree = new(docTree)
+	}
+
+	return t.tree.get(t.doc)
+}
+
+// node returns the element of t in the tree of its document
+func (t *Tag) node() *Node {
+	return t.document().element(t.StartIndex)
+}
+
+// Parent returns the element which contains t, or nil if t is a top-level element.
+// The elements are found in the document where t was found; the omitted elements, e.g. html or tbody, are not created.
+func (t *Tag) Parent() *Tag {
+	if t == nil {
+		return nil
+	}
+
+	return t.navigate(parentElement)
+}
+
+// Ancestors returns the elements which contain t, starting with its parent.
+func (t *Tag) Ancestors() []*Tag {
+	var tags []*Tag
+	for p := t.Parent(); p != nil; p = p.Parent() {
+		tags = append(tags, p)
+	}
+
+	return tags
+}
+
+// Children returns the child elements of t, in the document order.
+func (t *Tag) Children() []*Tag {
+	var tags []*Tag
+	for c := t.FirstChild(); c != nil; c = c.NextSibling() {
+		tags = append(tags, c)
+	}
+
+	return tags
+}
+
+// FirstChild returns the first child element of t, or nil if there is none.
+func (t *Tag) FirstChild() *Tag {
+	if t == nil {
+		return nil
+	}
+
+	return t.navigate(func(n *Node) *Node {
+		c := n.FirstChild
+		if c != nil && c.Type != ElementNode {
+			c = nextElement(c)
+		}
+		return c
+	})
+}
+
+// LastChild returns the last child element of t, or nil if there is none.
+func (t *Tag) LastChild() *Tag {
+	if t == nil {
+		return nil
+	}
+
+	return t.navigate(func(n *Node) *Node {
+		c := n.LastChild
+		if c != nil && c.Type != ElementNode {
+			c = prevElement(c)
+		}
+		return c
+	})
+}
+
+// NextSibling returns the next element with the same parent as t, or nil if there is none.
+func (t *Tag) NextSibling() *Tag {
+	if t == nil {
+		return nil
+	}
+
+	return t.navigate(nextElement)
+}
+
+// PrevSibling returns the previous element with the same parent as t, or nil if there is none.
+func (t *Tag) PrevSibling() *Tag {
+	if t == nil {
+		return nil
+	}
+
+	return t.navigate(prevElement)
+}
+
+// navigate returns the tag of the element returned by f for the element of t, or nil
+func (t *Tag) navigate(f func(*Node) *Node) *Tag {
+	n := t.node()
+	if n == nil {
+		return nil
+	}
+
+	return f(n).Tag()
+}
@@ -0,0 +1,78 @@
+package tag
+
+import (
+	"strings"
+	"testing"
+)
+
+// ids returns the ids of tags separated by spaces
+func ids(tags ...*Tag) string {
+	var s []string
+	for _, t := range tags {
+		if t == nil {
+			s = append(s, "nil")
+			continue
+		}
+		s = append(s, t.Attr["id"])
+	}
+
+	return strings.Join(s, " ")
+}
+
+func TestTag_navigation(t *testing.T) {
+	doc := `<div id=d>text<h1 id=h>T</h1><!-- c --><ul id=u><li id=l1>1<li id=l2><a id=a href=/>2</a><li id=l3>3</ul><p id=p>x</div><br id=b>`
+
+	tests := []struct {
+		name string
+		got  func(*Tag) string
+		id   string
+		want string
+	}{
+		{"Parent", func(t *Tag) string { return ids(t.Parent()) }, "a", "l2"},
+		{"Parent/implied end", func(t *Tag) string { return ids(t.Parent()) }, "l3", "u"},
+		{"Parent/top-level", func(t *Tag) string { return ids(t.Parent()) }, "d", "nil"},
+		{"Ancestors", func(t *Tag) string { return ids(t.Ancestors()...) }, "a", "l2 u d"},
+		{"Ancestors/top-level", func(t *Tag) string { return ids(t.Ancestors()...) }, "b", ""},
+		{"Children", func(t *Tag) string { return ids(t.Children()...) }, "d", "h u p"},
+		{"Children/implied end", func(t *Tag) string { return ids(t.Children()...) }, "u", "l1 l2 l3"},
+		{"Children/none", func(t *Tag) string { return ids(t.Children()...) }, "l1", ""},
+		{"FirstChild", func(t *Tag) string { return ids(t.FirstChild()) }, "d", "h"},
+		{"FirstChild/none", func(t *Tag) string { return ids(t.FirstChild()) }, "b", "nil"},
+		{"LastChild", func(t *Tag) string { return ids(t.LastChild()) }, "u", "l3"},
+		{"LastChild/text", func(t *Tag) string { return ids(t.LastChild()) }, "l2", "a"},
+		{"NextSibling", func(t *Tag) string { return ids(t.NextSibling()) }, "h", "u"},
+		{"NextSibling/top-level", func(t *Tag) string { return ids(t.NextSibling()) }, "d", "b"},
+		{"NextSibling/last", func(t *Tag) string { return ids(t.NextSibling()) }, "p", "nil"},
+		{"PrevSibling", func(t *Tag) string { return ids(t.PrevSibling()) }, "u", "h"},
+		{"PrevSibling/first", func(t *Tag) string { return ids(t.PrevSibling()) }, "h", "nil"},
+		{"chain", func(t *Tag) string { return ids(t.Parent().PrevSibling().Parent().PrevSibling()) }, "a", "h"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tag *Tag
+			for _, n := range []string{"div", "h1", "ul", "li", "a", "p", "br"} {
+				if tag == nil {
+					tag = Find(doc, n, []Check{Equal("id", tt.id)})
+				}
+			}
+
+			if got := tt.got(tag); got != tt.want {
+				t.Errorf("%s() = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+
+	// the navigation from a nil tag returns nil
+	var tag *Tag
+	if tag.Parent() != nil || tag.Ancestors() != nil || tag.Children() != nil || tag.FirstChild() != nil ||
+		tag.LastChild() != nil || tag.NextSibling() != nil || tag.PrevSibling() != nil {
+		t.Errorf("navigation from nil returns a tag")
+	}
+
+	// the returned tags are the same as found by Find
+	a := Find(doc, "li", []Check{Equal("id", "l2")}).FirstChild()
+	want := Find(doc, "a", nil)
+	if a.StartIndex != want.StartIndex || a.ContentIndex != want.ContentIndex || a.AfterClosureIndex != want.AfterClosureIndex || a.Content() != "2" {
+		t.Errorf("FirstChild() = %v, want %v", a, want)
+	}
+}
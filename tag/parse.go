@@ -0,0 +1,286 @@
+package tag
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// NodeType is a type of Node.
+type NodeType int
+
+const (
+	DocumentNode NodeType = iota // The root of the tree.
+	ElementNode                  // An element, e.g. <a href="/">text</a>.
+	TextNode                     // A text, including the content of a CDATA section.
+	CommentNode                  // A comment, e.g. <!-- text -->.
+	DoctypeNode                  // A document type declaration, e.g. <!DOCTYPE html>.
+)
+
+// String returns the name of nt.
+func (nt NodeType) String() string {
+	switch nt {
+	case DocumentNode:
+		return "Document"
+	case ElementNode:
+		return "Element"
+	case TextNode:
+		return "Text"
+	case CommentNode:
+		return "Comment"
+	case DoctypeNode:
+		return "Doctype"
+	}
+
+	return "Invalid"
+}
+
+// Node is a representation of an element, text, comment or document type declaration in the tree of a Document.
+type Node struct {
+	Type        NodeType          // The type of the node.
+	Name        string            // The name of the element in lowercase. Empty for other nodes.
+	Attr        map[string]string // The map of attributes of the element map[attr_name]attr_val. Nil for other nodes.
+	Data        string            // The text with character references replaced, the content of the comment or the document type declaration. Empty for the elements.
+	Start       int               // The index of the node's first byte in the document.
+	End         int               // The index of the next byte after the node in the document. For the elements, it is the end of the closing tag or the point where the element is closed implicitly.
+	Parent      *Node             // The parent node; nil for the root.
+	FirstChild  *Node             // The first child node.
+	LastChild   *Node             // The last child node.
+	PrevSibling *Node             // The previous node with the same parent.
+	NextSibling *Node             // The next node with the same parent.
+	content     int               // The index of the next byte after the element's opening tag.
+	closure     int               // AfterClosureIndex of the element's Tag.
+	misnested   bool              // True if the element is closed only because a tag closes its ancestor; Find searches further for its closure.
+	selfClosing bool              // True if the element's opening tag is closed with "/>".
+	doc         *Document         // The document of the node.
+}
+
+// Document is a tree of nodes parsed from an HTML document.
+// The tree follows the markup: the elements which are missing in the document, e.g. html, body or tbody, are not created.
+type Document struct {
+	Root     *Node   // The root of the tree of type DocumentNode; its children are the top-level nodes of the document.
+	Source   string  // The parsed document.
+	elements []*Node // The elements in the document order.
+}
+
+// Parse returns the tree of nodes of the HTML document s.
+// The elements are closed by their closing tags or implicitly, using the same rules as Find.
+// An element left open when a tag closes its ancestor ends there in the tree, but its Tag has the closure found by Find.
+// As in a browser, any string is a valid document, so the returned error is always nil.
+func Parse(s string) (*Document, error) {
+	d := &Document{Source: s}
+	d.Root = &Node{Type: DocumentNode, End: len(s), doc: d}
+
+	// the stack of open elements
+	var open []*Node
+	var names openElements
+
+	// parent returns the node to which the next node is appended
+	parent := func() *Node {
+		if len(open) == 0 {
+			return d.Root
+		}
+		return open[len(open)-1]
+	}
+
+	// closeAll closes the open elements starting at the index i of the stack at the index end of s, which is the beginning of the tag m.
+	// rules maps the names of the elements to the sets of tags which close them implicitly.
+	closeAll := func(i, end int, m string, rules map[string]map[string]bool) {
+		for _, e := range open[i:] {
+			e.End = end
+			e.closure = end
+			// the other elements are closed only because they are inside a closed one
+			e.misnested = !rules[e.Name][m]
+		}
+		open = open[:i]
+		names.truncate(i)
+	}
+
+	z := newTokenizer(s, 0, "")
+	for {
+		tok, attr, err := z.next()
+		if err != nil {
+			// the end of the document
+			break
+		}
+
+		node := &Node{Start: tok.Start, End: tok.End, doc: d}
+
+		switch tok.Type {
+		case StartTagToken:
+			// close the elements which are closed implicitly by the tag
+			closeAll(names.closeByStartTag(tok.Name), tok.Start, tok.Name, closedByStartTag)
+
+			node.Type = ElementNode
+			node.Name = tok.Name
+			node.Attr = parseAttribute(attr)
+			node.content = tok.End
+			node.closure = tok.End
+			node.selfClosing = tok.SelfClosing
+			parent().appendChild(node)
+			d.elements = append(d.elements, node)
+
+			if !closedByOpeningTag(tok.Name, tok.SelfClosing) {
+				open = append(open, node)
+				names.push(tok.Name)
+			}
+			continue
+		case EndTagToken:
+			i, explicit := names.closeByEndTag(tok.Name)
+			if i == len(open) {
+				// there is no such open element; ignore the tag
+				continue
+			}
+
+			if explicit {
+				// the element is closed by its own closing tag, and the elements opened after it end where the tag starts
+				e := open[i]
+				closeAll(i+1, tok.Start, tok.Name, closedByEndTag)
+				closeAll(i, tok.End, tok.Name, nil)
+				e.misnested = false
+				continue
+			}
+
+			// the elements end where the closing tag of their parent starts
+			closeAll(i, tok.Start, tok.Name, closedByEndTag)
+			continue
+		case TextToken:
+			node.Type = TextNode
+			node.Data = tok.Data
+			if p := parent(); p.Type != ElementNode || !rawTextElements[p.Name] || p.Name == "title" || p.Name == "textarea" {
+				// the content of the raw text elements other than the escapable ones is left as it is
+				node.Data = unescape(tok.Data, false)
+			}
+		case CDATAToken:
+			node.Type = TextNode
+			node.Data = strings.TrimSuffix(tok.Data[len("<![CDATA["):], "]]>")
+		case CommentToken:
+			node.Type = CommentNode
+			node.Data = commentData(tok.Data)
+		case DoctypeToken:
+			node.Type = DoctypeNode
+			node.Data = strings.TrimSpace(strings.TrimSuffix(tok.Data[len("<!DOCTYPE"):], ">"))
+		}
+
+		parent().appendChild(node)
+	}
+
+	// the elements which are not closed last until the end of the document
+	for _, e := range open {
+		e.End = len(s)
+		e.closure = len(s)
+		if !hasOptionalEndTag(e.Name) {
+			// there is no closure
+			e.closure = -1
+		}
+	}
+
+	return d, nil
+}
+
+// commentData returns the content of the comment c
+func commentData(c string) string {
+	switch {
+	case c == "<!-->" || c == "<!--->":
+		// empty comment
+		return ""
+	case strings.HasPrefix(c, "<!--"):
+		// the comment might not be closed
+		return strings.TrimSuffix(c[len("<!--"):], "-->")
+	case strings.HasPrefix(c, "</"):
+		// bogus comment, e.g. </ x>
+		c = c[len("</"):]
+	default:
+		// bogus comment, e.g. <?xml?> or <!x>
+		c = c[1:]
+	}
+
+	return strings.TrimSuffix(c, ">")
+}
+
+// appendChild adds c as the last child of n
+func (n *Node) appendChild(c *Node) {
+	c.Parent = n
+	if n.LastChild == nil {
+		n.FirstChild = c
+	} else {
+		n.LastChild.NextSibling = c
+		c.PrevSibling = n.LastChild
+	}
+	n.LastChild = c
+}
+
+// Tag returns a *Tag struct representing the element n. It has the same fields as the one returned by Find for this element.
+// Returns nil if n is not an element.
+func (n *Node) Tag() *Tag {
+	if n == nil || n.Type != ElementNode {
+		return nil
+	}
+
+	s := n.doc.Source
+	closure := n.closure
+	if n.misnested {
+		// the element does not end with its ancestor, so its closure is searched for as in Find
+		closure = getAfterClosureIndex(s, n.Name, n.content)
+	}
+
+	// the tag gets its own copy of the attributes, so changing them does not change the tree
+	attr := make(map[string]string, len(n.Attr))
+	for k, v := range n.Attr {
+		attr[k] = v
+	}
+
+	return &Tag{
+		Name:              n.Name,
+		RawName:           s[n.Start+1 : n.Start+1+len(n.Name)],
+		Attr:              attr,
+		ContentIndex:      n.content,
+		AfterClosureIndex: closure,
+		SelfClosing:       n.selfClosing,
+		StartIndex:        n.Start,
+		doc:               s,
+		tree:              &docTree{tree: n.doc},
+	}
+}
+
+// Matches checks if n is an element which satisfies all f functions.
+func (n *Node) Matches(f []Check) bool {
+	t := n.Tag()
+	if t == nil {
+		return false
+	}
+
+	return passChecks(f, t)
+}
+
+// element returns the element of d whose opening tag starts at the index start, or nil if there is no such element.
+func (d *Document) element(start int) *Node {
+	// the elements are sorted by their starts
+	i := sort.Search(len(d.elements), func(i int) bool {
+		return d.elements[i].Start >= start
+	})
+	if i == len(d.elements) || d.elements[i].Start != start {
+		return nil
+	}
+
+	return d.elements[i]
+}
+
+// docTree holds the tree of a document, which is parsed when it is needed for the first time.
+// It is shared by a query and the tags found by it, so the document is parsed at most once for all of them and the tree is freed together with them.
+type docTree struct {
+	once sync.Once
+	tree *Document
+}
+
+// get returns the tree of the document s
+func (dt *docTree) get(s string) *Document {
+	dt.once.Do(func() {
+		if dt.tree == nil {
+			// Parse does not fail for a string
+			dt.tree, _ = Parse(s)
+		}
+	})
+
+	return dt.tree
+}
@@ -0,0 +1,127 @@
+package tag
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// dump returns a string representation of the children of n with their spans
+func dump(n *Node) string {
+	var parts []string
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		var s string
+		switch c.Type {
+		case ElementNode:
+			s = fmt.Sprintf("%s[%d:%d](%s)", c.Name, c.Start, c.End, dump(c))
+		default:
+			s = fmt.Sprintf("%s[%d:%d]%q", c.Type, c.Start, c.End, c.Data)
+		}
+		parts = append(parts, s)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{
+			"1",
+			`<!DOCTYPE html><p class=x>a &amp; b<br>c</p><!-- x -->`,
+			`Doctype[0:15]"html" p[15:44](Text[26:35]"a & b" br[35:39]() Text[39:40]"c") Comment[44:54]" x "`,
+		},
+		{
+			"2",
+			`<ul><li>One<li>Two</ul>`,
+			`ul[0:23](li[4:11](Text[8:11]"One") li[11:18](Text[15:18]"Two"))`,
+		},
+		{
+			"3",
+			`<div><span>x</div></span>y`,
+			`div[0:18](span[5:12](Text[11:12]"x")) Text[25:26]"y"`,
+		},
+		{
+			"4",
+			`<script>a&amp;"<b>"</script><title>&lt;</title><![CDATA[<c>]]>`,
+			`script[0:28](Text[8:19]"a&amp;\"<b>\"") title[28:47](Text[35:39]"<") Text[47:62]"<c>"`,
+		},
+		{
+			"5",
+			`<table><tr><td>1<td>2<tr><td>3</table><p>end`,
+			`table[0:38](tr[7:21](td[11:16](Text[15:16]"1") td[16:21](Text[20:21]"2")) tr[21:30](td[25:30](Text[29:30]"3"))) p[38:44](Text[41:44]"end")`,
+		},
+		{
+			"6",
+			`<svg><path/><circle r=1 /></svg><?xml x?>`,
+			`svg[0:32](path[5:12]() circle[12:26]()) Comment[32:41]"?xml x?"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := Parse(tt.doc)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := dump(d.Root); got != tt.want {
+				t.Errorf("Parse() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNode_Tag(t *testing.T) {
+	doc := `<html><body><div id="a"><ul><li>One<li>Two</ul><p>x<br/>y</div><div><img src="/i.png"><span>z</div><b><i>w</b></i></body></html>`
+	d, err := Parse(doc)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	// every element has the same *Tag as the one found by Find, also if its closing tag is missing or misplaced
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != ElementNode {
+				continue
+			}
+
+			want := Find(doc, c.Name, nil)
+			for want != nil && want.StartIndex != c.Start {
+				want = want.Next()
+			}
+			got := c.Tag()
+			if got.tree.get(doc) != d {
+				t.Errorf("Node.Tag().tree = %p, want %p", got.tree.get(doc), d)
+			}
+
+			// the tag found by Find has the tree of its own query
+			got.tree = want.tree
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Node.Tag() = %v, want %v", got, want)
+			}
+			if got.Content() != want.Content() {
+				t.Errorf("Node.Tag().Content() = %q, want %q", got.Content(), want.Content())
+			}
+			if got, want := c.Matches([]Check{Equal("id", "a")}), c.Attr["id"] == "a"; got != want {
+				t.Errorf("Node.Matches() = %v, want %v for %v", got, want, c.Attr)
+			}
+
+			// the attributes of the tag are not shared with the tree
+			got.Attr["id"] = "changed"
+			if c.Attr["id"] == "changed" {
+				t.Errorf("Node.Tag().Attr is shared with Node.Attr")
+			}
+
+			walk(c)
+		}
+	}
+	walk(d.Root)
+
+	if tag := d.Root.Tag(); tag != nil {
+		t.Errorf("Node.Tag() = %v, want %v", tag, nil)
+	}
+}
@@ -0,0 +1,878 @@
+package tag
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Selector is a compiled CSS selector, e.g. "div#main > ul.items li a[href^='https']:not(.ad)".
+// It is safe for concurrent use.
+type Selector struct {
+	source string        // The selector as it was compiled.
+	list   []*complexSel // The comma-separated selectors; an element matches if it matches any of them.
+}
+
+// complexSel is a sequence of compound selectors separated by combinators, e.g. "div > p a".
+type complexSel struct {
+	compounds   []compound // The compound selectors from left to right.
+	combinators []byte     // The combinator between compounds[i] and compounds[i+1]: ' ', '>', '+' or '~'.
+}
+
+// compound is a sequence of simple selectors without combinators, e.g. "a.ad[href]".
+type compound struct {
+	name     string             // The lowercase name of the element; empty for any element.
+	matchers []func(*Node) bool // The id, class, attribute and pseudo-class selectors.
+	scope    bool               // True if it stands for the element against which a relative selector of :has is matched.
+}
+
+// Compile parses the CSS selector sel and returns a Selector which can be used to find elements.
+//
+// Supported are type (including "*"), id, class and attribute selectors with the operators =, ~=, |=, ^=, $= and *=
+// and the optional i or s flag, the descendant (" "), child (">"), next-sibling ("+") and subsequent-sibling ("~") combinators,
+// comma-separated lists, and the pseudo-classes :not, :is, :where, :has, :root, :empty, :first-child, :last-child, :only-child,
+// :first-of-type, :last-of-type, :only-of-type, :nth-child, :nth-last-child, :nth-of-type and :nth-last-of-type.
+func Compile(sel string) (*Selector, error) {
+	p := &selParser{s: sel}
+	p.skipSpace()
+	list, err := p.parseList(false)
+	if err != nil {
+		return nil, err
+	}
+	if p.i < len(p.s) {
+		return nil, p.errorf("unexpected %q", p.s[p.i])
+	}
+
+	return &Selector{source: sel, list: list}, nil
+}
+
+// MustCompile is like Compile but panics if sel cannot be parsed.
+// It is useful for the selectors in global variables.
+func MustCompile(sel string) *Selector {
+	s, err := Compile(sel)
+	if err != nil {
+		panic(err)
+	}
+
+	return s
+}
+
+// Select returns the first element of the document s which matches the CSS selector sel, or nil if there is no such element.
+// Next called on the returned Tag returns the next element with the same name which matches sel.
+// The error is returned if sel cannot be parsed.
+func Select(s, sel string) (*Tag, error) {
+	c, err := Compile(sel)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Select(s), nil
+}
+
+// SelectAll returns all elements of the document s which match the CSS selector sel, in the document order.
+// The error is returned if sel cannot be parsed.
+func SelectAll(s, sel string) ([]*Tag, error) {
+	c, err := Compile(sel)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.SelectAll(s), nil
+}
+
+// String returns the source of the selector.
+func (sel *Selector) String() string {
+	return sel.source
+}
+
+// Select returns the first element of the document s which matches sel, or nil if there is no such element.
+func (sel *Selector) Select(s string) *Tag {
+	var found *Tag
+	sel.each(s, func(t *Tag) bool {
+		found = t
+		return false
+	})
+
+	return found
+}
+
+// SelectAll returns all elements of the document s which match sel, in the document order.
+func (sel *Selector) SelectAll(s string) []*Tag {
+	var found []*Tag
+	sel.each(s, func(t *Tag) bool {
+		found = append(found, t)
+		return true
+	})
+
+	return found
+}
+
+// Match checks if n is an element which matches sel.
+func (sel *Selector) Match(n *Node) bool {
+	if n == nil || n.Type != ElementNode {
+		return false
+	}
+
+	return matchList(sel.list, n, nil)
+}
+
+// Check returns a Check function which determines if the tag matches sel in its document.
+// It allows using selectors together with other Check functions, e.g. Find(s, "a", []Check{sel.Check(), Has("href")}).
+func (sel *Selector) Check() Check {
+	return func(t *Tag) bool {
+		return sel.Match(t.node())
+	}
+}
+
+// each calls f for the elements of the document s which match sel, in the document order, until f returns false
+func (sel *Selector) each(s string, f func(*Tag) bool) {
+	// the tree is shared with the found tags
+	tree := new(docTree)
+	d := tree.get(s)
+	checks := []Check{sel.Check()}
+
+	// walk the tree in the document order
+	for n := d.Root.FirstChild; n != nil; n = nextInOrder(n, d.Root) {
+		if !sel.Match(n) {
+			continue
+		}
+
+		t := n.Tag()
+		t.checks = checks
+		t.tree = tree
+		if !f(t) {
+			return
+		}
+	}
+}
+
+// nextInOrder returns the node following n in the document order inside root, or nil at the end of root
+func nextInOrder(n, root *Node) *Node {
+	if n.FirstChild != nil {
+		return n.FirstChild
+	}
+
+	for ; n != root; n = n.Parent {
+		if n.NextSibling != nil {
+			return n.NextSibling
+		}
+	}
+
+	return nil
+}
+
+// matchList checks if n matches any of the selectors of list; anchor is the element against which relative selectors are matched
+func matchList(list []*complexSel, n, anchor *Node) bool {
+	for _, c := range list {
+		if c.match(n, len(c.compounds)-1, anchor) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// match checks if n matches the compound selector i of c and the part of c on its left
+func (c *complexSel) match(n *Node, i int, anchor *Node) bool {
+	if !c.compounds[i].match(n, anchor) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+
+	switch c.combinators[i-1] {
+	case '>':
+		p := parentElement(n)
+		return p != nil && c.match(p, i-1, anchor)
+	case '+':
+		p := prevElement(n)
+		return p != nil && c.match(p, i-1, anchor)
+	case '~':
+		for p := prevElement(n); p != nil; p = prevElement(p) {
+			if c.match(p, i-1, anchor) {
+				return true
+			}
+		}
+	default:
+		for p := parentElement(n); p != nil; p = parentElement(p) {
+			if c.match(p, i-1, anchor) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// match checks if n matches all simple selectors of c
+func (c *compound) match(n *Node, anchor *Node) bool {
+	if c.scope {
+		return n == anchor
+	}
+	if c.name != "" && c.name != n.Name {
+		return false
+	}
+
+	for _, m := range c.matchers {
+		if !m(n) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parentElement returns the parent of n if it is an element, or nil
+func parentElement(n *Node) *Node {
+	if n.Parent == nil || n.Parent.Type != ElementNode {
+		return nil
+	}
+
+	return n.Parent
+}
+
+// prevElement returns the previous sibling element of n, or nil
+func prevElement(n *Node) *Node {
+	for n = n.PrevSibling; n != nil; n = n.PrevSibling {
+		if n.Type == ElementNode {
+			return n
+		}
+	}
+
+	return nil
+}
+
+// nextElement returns the next sibling element of n, or nil
+func nextElement(n *Node) *Node {
+	for n = n.NextSibling; n != nil; n = n.NextSibling {
+		if n.Type == ElementNode {
+			return n
+		}
+	}
+
+	return nil
+}
+
+// matchAttr checks if the attribute value v matches the value s with the CSS attribute selector operator op.
+// If fold is true, the values are compared ASCII case-insensitively.
+func matchAttr(op, v, s string, fold bool) bool {
+	if fold {
+		v, s = toLowerASCII(v), toLowerASCII(s)
+	}
+
+	switch op {
+	case "=":
+		return v == s
+	case "~=":
+		return s != "" && !strings.ContainsAny(s, " \t\n\f\r") && hasField(v, s)
+	case "|=":
+		return v == s || strings.HasPrefix(v, s+"-")
+	case "^=":
+		return s != "" && strings.HasPrefix(v, s)
+	case "$=":
+		return s != "" && strings.HasSuffix(v, s)
+	case "*=":
+		return s != "" && strings.Contains(v, s)
+	}
+
+	return false
+}
+
+// nth checks if the position i (counting from 1) is described by the an+b formula
+func nth(a, b, i int) bool {
+	if a == 0 {
+		return i == b
+	}
+
+	return (i-b)/a >= 0 && (i-b)%a == 0
+}
+
+// position returns the position of n among the sibling elements for which f returns true, counting from 1.
+// If last is true, the elements are counted from the end.
+func position(n *Node, last bool, f func(*Node) bool) int {
+	sibling := prevElement
+	if last {
+		sibling = nextElement
+	}
+
+	i := 1
+	for s := sibling(n); s != nil; s = sibling(s) {
+		if f(s) {
+			i++
+		}
+	}
+
+	return i
+}
+
+// selParser is a parser of CSS selectors
+type selParser struct {
+	s string // The selector.
+	i int    // The index of the next byte to read.
+}
+
+// errorf returns an error at the current position of p
+func (p *selParser) errorf(format string, a ...any) error {
+	return fmt.Errorf("tag: invalid selector %q at offset %d: %s", p.s, p.i, fmt.Sprintf(format, a...))
+}
+
+// skipSpace skips the white spaces and reports if there were any
+func (p *selParser) skipSpace() bool {
+	start := p.i
+	for p.i < len(p.s) && isSpace(p.s[p.i]) {
+		p.i++
+	}
+
+	return p.i > start
+}
+
+// peek returns the next byte or 0 at the end of the selector
+func (p *selParser) peek() byte {
+	if p.i < len(p.s) {
+		return p.s[p.i]
+	}
+
+	return 0
+}
+
+// parseList parses comma-separated complex selectors; if relative is true, they might start with a combinator, as in :has
+func (p *selParser) parseList(relative bool) ([]*complexSel, error) {
+	var list []*complexSel
+	for {
+		c, err := p.parseComplex(relative)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+
+		if p.peek() != ',' {
+			return list, nil
+		}
+		p.i++
+		p.skipSpace()
+	}
+}
+
+// parseComplex parses compound selectors separated by combinators, up to the end of the selector, ',' or ')'
+func (p *selParser) parseComplex(relative bool) (*complexSel, error) {
+	c := &complexSel{}
+	if relative {
+		// the selector is matched against the element of :has, which is on the left of the first combinator
+		c.compounds = append(c.compounds, compound{scope: true})
+		comb := byte(' ')
+		if b := p.peek(); b == '>' || b == '+' || b == '~' {
+			comb = b
+			p.i++
+			p.skipSpace()
+		}
+		c.combinators = append(c.combinators, comb)
+	}
+
+	for {
+		comp, err := p.parseCompound()
+		if err != nil {
+			return nil, err
+		}
+		c.compounds = append(c.compounds, comp)
+
+		space := p.skipSpace()
+		b := p.peek()
+		if b == 0 || b == ',' || b == ')' {
+			return c, nil
+		}
+
+		comb := byte(' ')
+		switch {
+		case b == '>' || b == '+' || b == '~':
+			comb = b
+			p.i++
+			p.skipSpace()
+		case !space:
+			return nil, p.errorf("unexpected %q", b)
+		}
+		c.combinators = append(c.combinators, comb)
+	}
+}
+
+// parseCompound parses a type selector followed by id, class, attribute and pseudo-class selectors
+func (p *selParser) parseCompound() (compound, error) {
+	var c compound
+	start := p.i
+
+	switch {
+	case p.peek() == '*':
+		p.i++
+	case p.isIdentStart():
+		name, err := p.parseIdent()
+		if err != nil {
+			return c, err
+		}
+		c.name = toLowerASCII(name)
+	}
+
+	for {
+		var m func(*Node) bool
+		var err error
+
+		switch p.peek() {
+		case '#':
+			p.i++
+			m, err = p.parseID()
+		case '.':
+			p.i++
+			m, err = p.parseClass()
+		case '[':
+			p.i++
+			m, err = p.parseAttr()
+		case ':':
+			p.i++
+			m, err = p.parsePseudo()
+		default:
+			if p.i == start {
+				if p.i == len(p.s) {
+					return c, p.errorf("missing selector")
+				}
+				return c, p.errorf("unexpected %q", p.s[p.i])
+			}
+			return c, nil
+		}
+		if err != nil {
+			return c, err
+		}
+
+		c.matchers = append(c.matchers, m)
+	}
+}
+
+// parseID parses the name of an id selector
+func (p *selParser) parseID() (func(*Node) bool, error) {
+	id, err := p.parseName()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(n *Node) bool {
+		v, ok := n.Attr["id"]
+		return ok && v == id
+	}, nil
+}
+
+// parseClass parses the name of a class selector
+func (p *selParser) parseClass() (func(*Node) bool, error) {
+	class, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(n *Node) bool {
+		return hasField(n.Attr["class"], class)
+	}, nil
+}
+
+// parseAttr parses an attribute selector after '['
+func (p *selParser) parseAttr() (func(*Node) bool, error) {
+	p.skipSpace()
+	name, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	name = toLowerASCII(name)
+	p.skipSpace()
+
+	if p.peek() == ']' {
+		p.i++
+		return func(n *Node) bool {
+			_, ok := n.Attr[name]
+			return ok
+		}, nil
+	}
+
+	// read the operator
+	var op string
+	for _, o := range [...]string{"=", "~=", "|=", "^=", "$=", "*="} {
+		if strings.HasPrefix(p.s[p.i:], o) {
+			op = o
+			break
+		}
+	}
+	if op == "" {
+		return nil, p.errorf("expected an attribute selector operator")
+	}
+	p.i += len(op)
+	p.skipSpace()
+
+	// read the value, which is either an identifier or a string
+	var value string
+	if b := p.peek(); b == '"' || b == '\'' {
+		value, err = p.parseString()
+	} else {
+		value, err = p.parseIdent()
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+
+	// read the optional flag
+	fold := false
+	switch p.peek() {
+	case 'i', 'I':
+		fold = true
+		p.i++
+		p.skipSpace()
+	case 's', 'S':
+		p.i++
+		p.skipSpace()
+	}
+
+	if p.peek() != ']' {
+		return nil, p.errorf("expected ']'")
+	}
+	p.i++
+
+	return func(n *Node) bool {
+		v, ok := n.Attr[name]
+		return ok && matchAttr(op, v, value, fold)
+	}, nil
+}
+
+// parsePseudo parses a pseudo-class after ':'
+func (p *selParser) parsePseudo() (func(*Node) bool, error) {
+	if p.peek() == ':' {
+		return nil, p.errorf("pseudo-elements are not supported")
+	}
+
+	name, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	name = toLowerASCII(name)
+
+	if p.peek() != '(' {
+		return p.simplePseudo(name)
+	}
+	p.i++
+	p.skipSpace()
+
+	var m func(*Node) bool
+	switch name {
+	case "not", "is", "where", "has":
+		relative := name == "has"
+		list, err := p.parseList(relative)
+		if err != nil {
+			return nil, err
+		}
+
+		switch name {
+		case "not":
+			m = func(n *Node) bool { return !matchList(list, n, nil) }
+		case "has":
+			m = func(n *Node) bool { return hasMatch(list, n) }
+		default:
+			m = func(n *Node) bool { return matchList(list, n, nil) }
+		}
+	case "nth-child", "nth-last-child", "nth-of-type", "nth-last-of-type":
+		a, b, err := p.parseNth()
+		if err != nil {
+			return nil, err
+		}
+
+		p.skipSpace()
+
+		// the siblings which are counted
+		var of []*complexSel
+		if (name == "nth-child" || name == "nth-last-child") && strings.HasPrefix(toLowerASCII(p.s[p.i:]), "of") && p.i+2 < len(p.s) && isSpace(p.s[p.i+2]) {
+			p.i += 2
+			p.skipSpace()
+			if of, err = p.parseList(false); err != nil {
+				return nil, err
+			}
+		}
+
+		last := strings.HasPrefix(name, "nth-last-")
+		ofType := strings.HasSuffix(name, "-of-type")
+		m = func(n *Node) bool {
+			f := func(s *Node) bool { return true }
+			switch {
+			case ofType:
+				f = func(s *Node) bool { return s.Name == n.Name }
+			case of != nil:
+				if !matchList(of, n, nil) {
+					return false
+				}
+				f = func(s *Node) bool { return matchList(of, s, nil) }
+			}
+
+			return nth(a, b, position(n, last, f))
+		}
+	default:
+		return nil, p.errorf("unsupported pseudo-class :%s()", name)
+	}
+
+	p.skipSpace()
+	if p.peek() != ')' {
+		return nil, p.errorf("expected ')'")
+	}
+	p.i++
+
+	return m, nil
+}
+
+// simplePseudo returns the matcher of the pseudo-class without arguments
+func (p *selParser) simplePseudo(name string) (func(*Node) bool, error) {
+	all := func(s *Node) bool { return true }
+
+	switch name {
+	case "root":
+		return func(n *Node) bool { return n.Parent != nil && n.Parent.Type == DocumentNode }, nil
+	case "empty":
+		return func(n *Node) bool {
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if c.Type == ElementNode || c.Type == TextNode {
+					return false
+				}
+			}
+			return true
+		}, nil
+	case "first-child":
+		return func(n *Node) bool { return position(n, false, all) == 1 }, nil
+	case "last-child":
+		return func(n *Node) bool { return position(n, true, all) == 1 }, nil
+	case "only-child":
+		return func(n *Node) bool { return position(n, false, all) == 1 && position(n, true, all) == 1 }, nil
+	case "first-of-type", "last-of-type", "only-of-type":
+		return func(n *Node) bool {
+			f := func(s *Node) bool { return s.Name == n.Name }
+			first := position(n, false, f) == 1
+			last := position(n, true, f) == 1
+			switch name {
+			case "first-of-type":
+				return first
+			case "last-of-type":
+				return last
+			}
+			return first && last
+		}, nil
+	}
+
+	return nil, p.errorf("unsupported pseudo-class :%s", name)
+}
+
+// hasMatch checks if any element relative to n matches any of the relative selectors of list
+func hasMatch(list []*complexSel, n *Node) bool {
+	for _, c := range list {
+		// the descendants of n for the descendant and child combinators, or the following siblings and their descendants
+		root := n
+		if c.combinators[0] == '+' || c.combinators[0] == '~' {
+			if root = n.Parent; root == nil {
+				continue
+			}
+		}
+
+		for e := root.FirstChild; e != nil; e = nextInOrder(e, root) {
+			if e.Type == ElementNode && c.match(e, len(c.compounds)-1, n) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// parseNth parses the an+b argument of the :nth-* pseudo-classes, e.g. "odd", "even", "3", "2n+1" or "-n + 3"
+func (p *selParser) parseNth() (a, b int, err error) {
+	rest := toLowerASCII(p.s[p.i:])
+	for _, k := range [...]string{"odd", "even"} {
+		if strings.HasPrefix(rest, k) && (len(rest) == len(k) || !isNameChar(rest[len(k)])) {
+			p.i += len(k)
+			if k == "odd" {
+				return 2, 1, nil
+			}
+			return 2, 0, nil
+		}
+	}
+
+	sign := 1
+	switch p.peek() {
+	case '-':
+		sign = -1
+		p.i++
+	case '+':
+		p.i++
+	}
+
+	d, ok := p.parseInt()
+	if b := p.peek(); b != 'n' && b != 'N' {
+		if !ok {
+			return 0, 0, p.errorf("invalid an+b expression")
+		}
+		return 0, sign * d, nil
+	}
+	p.i++
+
+	a = sign * d
+	if !ok {
+		a = sign
+	}
+
+	// the optional b part
+	start := p.i
+	p.skipSpace()
+	switch p.peek() {
+	case '-':
+		sign = -1
+	case '+':
+		sign = 1
+	default:
+		p.i = start
+		return a, 0, nil
+	}
+	p.i++
+	p.skipSpace()
+
+	d, ok = p.parseInt()
+	if !ok {
+		return 0, 0, p.errorf("invalid an+b expression")
+	}
+
+	return a, sign * d, nil
+}
+
+// parseInt parses a non-negative decimal number and reports if there was any
+func (p *selParser) parseInt() (int, bool) {
+	start := p.i
+	d := 0
+	for p.i < len(p.s) && '0' <= p.s[p.i] && p.s[p.i] <= '9' {
+		if d < 1<<20 {
+			d = d*10 + int(p.s[p.i]-'0')
+		}
+		p.i++
+	}
+
+	return d, p.i > start
+}
+
+// isIdentStart checks if an identifier starts at the current position
+func (p *selParser) isIdentStart() bool {
+	s := p.s[p.i:]
+	if strings.HasPrefix(s, "-") {
+		s = s[1:]
+	}
+	if s == "" {
+		return false
+	}
+
+	return s[0] == '-' || s[0] == '\\' || s[0] == '_' || isASCIILetter(s[0]) || s[0] >= utf8.RuneSelf
+}
+
+// parseIdent parses a CSS identifier, e.g. a type, class or attribute name
+func (p *selParser) parseIdent() (string, error) {
+	if !p.isIdentStart() {
+		return "", p.errorf("expected an identifier")
+	}
+
+	return p.parseName()
+}
+
+// parseName parses a sequence of name characters and escapes, e.g. the name of an id selector
+func (p *selParser) parseName() (string, error) {
+	var b strings.Builder
+	for p.i < len(p.s) {
+		c := p.s[p.i]
+		switch {
+		case c == '\\':
+			r, err := p.parseEscape()
+			if err != nil {
+				return "", err
+			}
+			b.WriteRune(r)
+		case isNameChar(c):
+			b.WriteByte(c)
+			p.i++
+		default:
+			if b.Len() == 0 {
+				return "", p.errorf("expected a name")
+			}
+			return b.String(), nil
+		}
+	}
+
+	if b.Len() == 0 {
+		return "", p.errorf("expected a name")
+	}
+
+	return b.String(), nil
+}
+
+// parseString parses a quoted string
+func (p *selParser) parseString() (string, error) {
+	quote := p.s[p.i]
+	p.i++
+
+	var b strings.Builder
+	for p.i < len(p.s) {
+		c := p.s[p.i]
+		switch {
+		case c == quote:
+			p.i++
+			return b.String(), nil
+		case c == '\\' && p.i+1 < len(p.s) && p.s[p.i+1] == '\n':
+			// an escaped new line continues the string
+			p.i += 2
+		case c == '\\':
+			r, err := p.parseEscape()
+			if err != nil {
+				return "", err
+			}
+			b.WriteRune(r)
+		case c == '\n':
+			return "", p.errorf("new line in a string")
+		default:
+			b.WriteByte(c)
+			p.i++
+		}
+	}
+
+	return "", p.errorf("unterminated string")
+}
+
+// parseEscape parses an escape sequence starting with '\', e.g. "\31 " or "\:"
+func (p *selParser) parseEscape() (rune, error) {
+	p.i++
+	if p.i == len(p.s) || p.s[p.i] == '\n' {
+		return 0, p.errorf("invalid escape")
+	}
+
+	// up to 6 hexadecimal digits, optionally followed by a white space
+	var r rune
+	start := p.i
+	for p.i < len(p.s) && p.i-start < 6 {
+		d := digitValue(p.s[p.i], 16)
+		if d == -1 {
+			break
+		}
+		r = r*16 + d
+		p.i++
+	}
+	if p.i > start {
+		if p.i < len(p.s) && isSpace(p.s[p.i]) {
+			p.i++
+		}
+		if r == 0 || r > utf8.MaxRune || (0xD800 <= r && r <= 0xDFFF) {
+			r = utf8.RuneError
+		}
+		return r, nil
+	}
+
+	// any other character stands for itself
+	r, size := utf8.DecodeRuneInString(p.s[p.i:])
+	p.i += size
+
+	return r, nil
+}
+
+// isNameChar checks if b might be a part of a CSS name; bytes of multi-byte characters are accepted
+func isNameChar(b byte) bool {
+	return isASCIIAlphanumeric(b) || b == '-' || b == '_' || b >= utf8.RuneSelf
+}
@@ -0,0 +1,178 @@
+package tag
+
+import (
+	"strings"
+	"testing"
+)
+
+const selectorDoc = `<div id="main">
+	<h1 id="h">Title</h1>
+	<ul id="items" class="items list">
+		<li id="l1"><a id="a1" href="https://example.com/1" class="link">1</a>
+		<li id="l2"><a id="a2" href="https://ads.example.com" class="link ad">2</a>
+		<li id="l3"><a id="a3" href="http://example.com/3" lang="en-US">3</a>
+		<li id="l4" class="empty"></li>
+	</ul>
+	<p id="p1">x<p id="p2" data-x="A b"><span id="s1">y</span>
+</div>
+<div id="side"><a id="a4" href="HTTPS://x">4</a></div>`
+
+func TestSelectAll(t *testing.T) {
+	tests := []struct {
+		sel  string
+		want string
+	}{
+		{"a", "a1 a2 a3 a4"},
+		{"A", "a1 a2 a3 a4"},
+		{"*", "main h items l1 a1 l2 a2 l3 a3 l4 p1 p2 s1 side a4"},
+		{"#items", "items"},
+		{".ad", "a2"},
+		{".link.ad", "a2"},
+		{".lin", ""},
+		{"li.empty", "l4"},
+		{"div#main > ul.items li a[href^='https']:not(.ad)", "a1"},
+		{"[lang]", "a3"},
+		{"[lang=en-US]", "a3"},
+		{"[lang=en-us]", ""},
+		{"[lang=en-us i]", "a3"},
+		{"[lang|=en]", "a3"},
+		{"[lang|=e]", ""},
+		{"[class~=ad]", "a2"},
+		{"[class~='']", ""},
+		{"[href^=https]", "a1 a2"},
+		{"[href^=https i]", "a1 a2 a4"},
+		{"[href$='/3']", "a3"},
+		{"[href*=ads]", "a2"},
+		{`[href*=""]`, ""},
+		{"[data-x='A b' s]", "p2"},
+		{"div a", "a1 a2 a3 a4"},
+		{"div > a", "a4"},
+		{"ul a", "a1 a2 a3"},
+		{"h1 + ul", "items"},
+		{"h1 ~ p", "p1 p2"},
+		{"h1 + p", ""},
+		{"li:first-child", "l1"},
+		{"li:last-child", "l4"},
+		{"a:only-child", "a1 a2 a3 a4"},
+		{"p:first-of-type", "p1"},
+		{"p:last-of-type", "p2"},
+		{"#main > :only-of-type", "h items"},
+		{"li:nth-child(2)", "l2"},
+		{"li:nth-child(odd)", "l1 l3"},
+		{"li:nth-child(even)", "l2 l4"},
+		{"li:nth-child(2n+1)", "l1 l3"},
+		{"li:nth-child(-n + 2)", "l1 l2"},
+		{"li:nth-child(n+3)", "l3 l4"},
+		{"li:nth-last-child(1)", "l4"},
+		{"li:nth-child(1 of :has(.ad))", "l2"},
+		{"#main > :nth-of-type(2)", "p2"},
+		{"#main > :nth-last-of-type(1)", "h items p2"},
+		{"li:has(.ad)", "l2"},
+		{"li:has(> a[lang])", "l3"},
+		{"h1:has(+ ul)", "h"},
+		{"h1:has(~ p span)", "h"},
+		{"div:has(span, .ad)", "main"},
+		{"li:empty", "l4"},
+		{":root", "main side"},
+		{"a:is(#a1, #a4)", "a1 a4"},
+		{"a:where(.ad)", "a2"},
+		{"h1, #s1", "h s1"},
+		{"#a\\32", "a2"},
+		{"#\\61 1", "a1"},
+		{`a:not([href*="example"], .x)`, "a4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sel, func(t *testing.T) {
+			tags, err := SelectAll(selectorDoc, tt.sel)
+			if err != nil {
+				t.Fatalf("SelectAll() error = %v", err)
+			}
+
+			var ids []string
+			for _, tag := range tags {
+				ids = append(ids, tag.Attr["id"])
+			}
+			if got := strings.Join(ids, " "); got != tt.want {
+				t.Errorf("SelectAll() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelect(t *testing.T) {
+	tag, err := Select(selectorDoc, "li a:not(.ad)")
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+
+	// Next continues with the same selector
+	var ids []string
+	for ; tag != nil; tag = tag.Next() {
+		ids = append(ids, tag.Attr["id"])
+	}
+	if got, want := strings.Join(ids, " "), "a1 a3"; got != want {
+		t.Errorf("Select().Next() = %q, want %q", got, want)
+	}
+
+	if tag := MustCompile("table").Select(selectorDoc); tag != nil {
+		t.Errorf("Select() = %v, want %v", tag, nil)
+	}
+
+	// the selector works together with the other checks
+	tag = Find(selectorDoc, "a", []Check{MustCompile("ul a").Check(), Has("lang")})
+	if tag == nil || tag.Attr["id"] != "a3" {
+		t.Errorf("Find() = %v, want a3", tag)
+	}
+
+	// the tree is parsed once for the tags found by the same search
+	tags := MustCompile("li").SelectAll(selectorDoc)
+	if len(tags) < 2 || tags[0].tree != tags[1].tree || tags[0].Next().tree != tags[0].tree {
+		t.Errorf("SelectAll() tags do not share the tree")
+	}
+	tags = FindAll(selectorDoc, "a", []Check{MustCompile("li > a").Check()})
+	if len(tags) < 2 || tags[0].tree != tags[1].tree || tags[0].tree.tree == nil {
+		t.Errorf("FindAll() tags do not share the tree parsed by Check")
+	}
+}
+
+func TestCompile_error(t *testing.T) {
+	tests := []string{
+		"",
+		" ",
+		"a,",
+		"a >",
+		"> a",
+		"a[",
+		"a[href",
+		"a[href=]",
+		"a[href=x y]",
+		"a[href!=x]",
+		`a[href="x]`,
+		"a:unknown",
+		"a:unknown(x)",
+		"a::before",
+		"a:not(",
+		"a:not()",
+		"li:nth-child(x)",
+		"li:nth-child(2n+)",
+		"#",
+		".1",
+		"a)",
+	}
+
+	for _, sel := range tests {
+		t.Run(sel, func(t *testing.T) {
+			if _, err := Compile(sel); err == nil {
+				t.Errorf("Compile(%q) error = nil", sel)
+			}
+		})
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("MustCompile() did not panic")
+		}
+	}()
+	MustCompile("a[")
+}
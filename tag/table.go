@@ -0,0 +1,200 @@
+package tag
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// maxColspan and maxRowspan are the limits of the colspan and rowspan attributes defined by the HTML specification.
+const (
+	maxColspan = 1000
+	maxRowspan = 65534
+)
+
+// Cell is a cell of a table returned by Table.
+type Cell struct {
+	Text   string // The text of the cell as returned by Text.
+	HTML   string // The content of the cell as returned by Content.
+	Header bool   // True for th and for the cells in thead.
+}
+
+// Grid is a table as a list of rows of cells. All rows have the same length.
+// A cell spanning several rows or columns is repeated in each of them, and the missing cells are empty.
+type Grid [][]Cell
+
+// Table returns the grid of the table t, with colspan and rowspan expanded.
+// The rows of thead, tbody and tfoot are returned in the document order; the cells of nested tables belong to the cells containing them.
+// Returns nil if t is not a table.
+func Table(t *Tag) Grid {
+	if t == nil || t.Name != "table" {
+		return nil
+	}
+
+	n := t.node()
+	if n == nil {
+		return nil
+	}
+
+	var rows [][]*Cell
+	var group []*Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch c.Name {
+		case "tr":
+			// the rows outside a row group form an implicit one
+			group = append(group, c)
+		case "thead", "tbody", "tfoot":
+			rows = append(rows, rowGroup(group, false)...)
+			group = nil
+
+			var g []*Node
+			for r := c.FirstChild; r != nil; r = r.NextSibling {
+				if r.Name == "tr" {
+					g = append(g, r)
+				}
+			}
+			rows = append(rows, rowGroup(g, c.Name == "thead")...)
+		}
+	}
+	rows = append(rows, rowGroup(group, false)...)
+
+	// make all rows of the same length
+	width := 0
+	for _, r := range rows {
+		if len(r) > width {
+			width = len(r)
+		}
+	}
+
+	grid := make(Grid, len(rows))
+	for i, r := range rows {
+		grid[i] = make([]Cell, width)
+		for j, c := range r {
+			if c != nil {
+				grid[i][j] = *c
+			}
+		}
+	}
+
+	return grid
+}
+
+// rowGroup returns the rows of the cells of the row group made of the tr elements; the cells span at most to the end of the group
+func rowGroup(trs []*Node, header bool) [][]*Cell {
+	rows := make([][]*Cell, len(trs))
+
+	for i, tr := range trs {
+		col := 0
+		for c := tr.FirstChild; c != nil; c = c.NextSibling {
+			if c.Name != "td" && c.Name != "th" {
+				continue
+			}
+
+			t := c.Tag()
+			cell := &Cell{Text: t.Text(), HTML: t.Content(), Header: header || c.Name == "th"}
+
+			colspan := span(c.Attr["colspan"], 1, maxColspan)
+			rowspan := span(c.Attr["rowspan"], 0, maxRowspan)
+			if rowspan == 0 || i+rowspan > len(rows) {
+				// the cell spans to the end of the group
+				rowspan = len(rows) - i
+			}
+
+			// skip the columns taken by the cells spanning from the previous rows
+			for col < len(rows[i]) && rows[i][col] != nil {
+				col++
+			}
+
+			for r := i; r < i+rowspan; r++ {
+				for len(rows[r]) < col+colspan {
+					rows[r] = append(rows[r], nil)
+				}
+				for k := col; k < col+colspan; k++ {
+					rows[r][k] = cell
+				}
+			}
+			col += colspan
+		}
+	}
+
+	return rows
+}
+
+// span returns the value of the colspan or rowspan attribute v; it is 1 if v is not a valid number or less than low, and at most high
+func span(v string, low, high int) int {
+	n, err := strconv.Atoi(trimSpace(v))
+	switch {
+	case err != nil || n < low:
+		return 1
+	case n > high:
+		return high
+	}
+
+	return n
+}
+
+// WriteCSV writes the text of the cells of g to w in the CSV format.
+func (g Grid) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	for _, row := range g {
+		record := make([]string, len(row))
+		for i, c := range row {
+			record[i] = c.Text
+		}
+
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// Records returns the rows of g below the header as maps from the header text to the text of the cells.
+// The header is the last of the leading rows consisting of header cells, e.g. th; it is the first row if there is no such row.
+// The header text is taken with white spaces collapsed as in TextEquals. If several columns have the same header, the first one is used.
+func (g Grid) Records() []map[string]string {
+	if len(g) == 0 {
+		return nil
+	}
+
+	// find the header
+	h := 0
+	for h+1 < len(g) && isHeaderRow(g[h+1]) && isHeaderRow(g[h]) {
+		h++
+	}
+
+	keys := make([]string, len(g[h]))
+	for i, c := range g[h] {
+		keys[i] = collapseSpace(c.Text)
+	}
+
+	records := make([]map[string]string, 0, len(g)-h-1)
+	for _, row := range g[h+1:] {
+		r := make(map[string]string, len(row))
+		for i, c := range row {
+			if _, ok := r[keys[i]]; !ok {
+				r[keys[i]] = c.Text
+			}
+		}
+		records = append(records, r)
+	}
+
+	return records
+}
+
+// isHeaderRow checks if the row has header cells and all its other cells are missing
+func isHeaderRow(row []Cell) bool {
+	header := false
+	for _, c := range row {
+		switch {
+		case c.Header:
+			header = true
+		case c != Cell{}:
+			return false
+		}
+	}
+
+	return header
+}
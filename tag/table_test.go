@@ -0,0 +1,135 @@
+package tag
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// texts returns the text of the cells of g, with the header cells marked with '*'
+func texts(g Grid) [][]string {
+	var rows [][]string
+	for _, r := range g {
+		var row []string
+		for _, c := range r {
+			if c.Header {
+				row = append(row, "*"+c.Text)
+				continue
+			}
+			row = append(row, c.Text)
+		}
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+func TestTable(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want [][]string
+	}{
+		{
+			"implied end tags",
+			`<table><tr><th>A<th>B<tr><td>1<td>2</table>`,
+			[][]string{{"*A", "*B"}, {"1", "2"}},
+		},
+		{
+			"colspan and rowspan",
+			`<table><tr><td rowspan=2>a<td colspan=2>b<tr><td>c<td>d<tr><td colspan=3>e</table>`,
+			[][]string{{"a", "b", "b"}, {"a", "c", "d"}, {"e", "e", "e"}},
+		},
+		{
+			"row groups",
+			`<table><caption>Cap</caption><thead><tr><td>H1<td>H2</thead><tbody><tr><td>1<td rowspan=0>x<tr><td>2</tbody><tfoot><tr><td>F</tfoot></table>`,
+			[][]string{{"*H1", "*H2"}, {"1", "x"}, {"2", "x"}, {"F", ""}},
+		},
+		{
+			"rowspan limited to group",
+			`<table><tbody><tr><td rowspan=5>a<td>b</tbody><tbody><tr><td>c</tbody></table>`,
+			[][]string{{"a", "b"}, {"c", ""}},
+		},
+		{
+			"missing cells and nested table",
+			`<table><tr><td>a<td><table><tr><td>n</table><tr><td colspan=x> b <i>c</i> </table>`,
+			[][]string{{"a", "n"}, {"b c", ""}},
+		},
+		{
+			"empty",
+			`<table></table>`,
+			[][]string(nil),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := texts(Table(Find(tt.doc, "table", nil))); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Table() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	g := Table(Find(`<table><tr><td><b>x</b></table>`, "table", nil))
+	if g[0][0].HTML != "<b>x</b>" {
+		t.Errorf("Table() HTML = %q, want %q", g[0][0].HTML, "<b>x</b>")
+	}
+
+	if g := Table(Find(`<div></div>`, "div", nil)); g != nil {
+		t.Errorf("Table() = %v, want nil", g)
+	}
+}
+
+func TestGrid_WriteCSV(t *testing.T) {
+	g := Table(Find(`<table><tr><th>Name<th>Note<tr><td>A, B<td>say "hi"<tr><td colspan=2>C</table>`, "table", nil))
+
+	var b strings.Builder
+	if err := g.WriteCSV(&b); err != nil {
+		t.Fatalf("Grid.WriteCSV() error = %v", err)
+	}
+
+	want := "Name,Note\n\"A, B\",\"say \"\"hi\"\"\"\nC,C\n"
+	if got := b.String(); got != want {
+		t.Errorf("Grid.WriteCSV() = %q, want %q", got, want)
+	}
+}
+
+func TestGrid_Records(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want []map[string]string
+	}{
+		{
+			"th",
+			`<table><tr><th colspan=2>Group<tr><th>Name<th> Unit
+ price</th><th>Name<tr><td>A<td>1<td>x<tr><td>B<td>2</table>`,
+			[]map[string]string{{"Name": "A", "Unit price": "1"}, {"Name": "B", "Unit price": "2"}},
+		},
+		{
+			"thead",
+			`<table><thead><tr><td>K<td>V</thead><tr><td>a<td>1</table>`,
+			[]map[string]string{{"K": "a", "V": "1"}},
+		},
+		{
+			"first row",
+			`<table><tr><td>K<td>V<tr><td>a<td>1</table>`,
+			[]map[string]string{{"K": "a", "V": "1"}},
+		},
+		{
+			"header only",
+			`<table><tr><th>K</table>`,
+			[]map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Table(Find(tt.doc, "table", nil)).Records(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Grid.Records() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := Grid(nil).Records(); got != nil {
+		t.Errorf("Grid.Records() = %v, want nil", got)
+	}
+}
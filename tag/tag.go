@@ -6,18 +6,24 @@ It does not implement the full HTML specification, so there might be cases where
 */
 package tag
 
-import (
-	"strings"
-)
+import "sort"
 
 // Tag is a representation of an HTML Tag found in doc.
 type Tag struct {
-	Name              string            // The name of the tag.
-	Attr              map[string]string // The map of attributes map[attr_name]attr_val. Attribute names are always lowercase.
+	Name              string            // The name of the tag. It is always lowercase.
+	RawName           string            // The name of the tag as it is written in doc.
+	Attr              map[string]string // The map of attributes map[attr_name]attr_val. Attribute names are always lowercase. Character references in values are replaced by the characters they represent.
+	StartIndex        int               // The index points to the first character of the opening tag in doc.
 	ContentIndex      int               // The index points to the next character after the opening tag's closure in doc (it might be outside the doc range).
-	AfterClosureIndex int               // The index points to the next character after the closing tag's closure in doc (it might be outside the doc range).
+	AfterClosureIndex int               // The index points to the next character after the closing tag's closure in doc (it might be outside the doc range). For the void and self-closing elements, it is equal to ContentIndex. If the closing tag is omitted, it points where the element ends.
+	SelfClosing       bool              // True if the opening tag is closed with "/>", e.g. <br/>.
 	doc               string            // A String where the tag was found.
 	checks            []Check           // A slice of check functions used to find the tag
+	names             []string          // The names used to find the tag by FindAny or with the "*" wildcard; nil if it was found by its name.
+	normalized        *string           // The text of the content used by the text checks; nil until it is needed.
+	tree              *docTree          // The tree of doc used for the navigation, shared with the tags found by the same query.
+	begin             int               // The index of doc where Prev stops searching, i.e. the beginning of the parent's content if the tag was found by the parent's Find.
+	end               int               // The index of doc where Next stops searching, i.e. the end of the parent's content if the tag was found by the parent's Find; 0 for the end of doc.
 }
 
 // Content returns a string between the starting tag and the closing tag of t.
@@ -27,16 +33,93 @@ func (t *Tag) Content() string {
 		return ""
 	}
 
-	if t.AfterClosureIndex < 1 {
-		// there is no closing tag; return empty content
+	if t.AfterClosureIndex <= t.ContentIndex {
+		// there is no closing tag or the element is void; return empty content
 		return ""
 	}
 
-	// find the index of the closing tag's beginning
-	closureIndex := strings.LastIndex(t.doc[t.ContentIndex:t.AfterClosureIndex], "</")
+	// return content between the opening tag and the closing tag, which might be omitted
+	return t.doc[t.ContentIndex:t.contentEnd()]
+}
+
+// InnerHTML returns the source of t's content, i.e. the string between the opening tag and the closing tag of t. It is the same as Content.
+func (t *Tag) InnerHTML() string {
+	return t.Content()
+}
+
+// OuterHTML returns the source of the whole element t, from the beginning of its opening tag to the end of its closing tag.
+// If the closing tag is omitted, it ends where the element ends. If there is no closure, only the opening tag is returned.
+func (t *Tag) OuterHTML() string {
+	if t == nil {
+		return ""
+	}
+
+	if t.AfterClosureIndex < t.ContentIndex {
+		// there is no closure
+		return t.StartTag()
+	}
+
+	return t.doc[t.StartIndex:t.AfterClosureIndex]
+}
+
+// StartTag returns the source of t's opening tag, e.g. <a href="/">.
+func (t *Tag) StartTag() string {
+	if t == nil {
+		return ""
+	}
+
+	return t.doc[t.StartIndex:t.ContentIndex]
+}
+
+// EndTag returns the source of t's closing tag, e.g. </a>. Returns an empty string if the closing tag is omitted or there is none.
+func (t *Tag) EndTag() string {
+	if t == nil || t.AfterClosureIndex <= t.ContentIndex {
+		return ""
+	}
+
+	return t.doc[t.contentEnd():t.AfterClosureIndex]
+}
+
+// contentEnd returns the index of the end of t's content in doc, i.e. the beginning of the closing tag or where t ends if it is omitted.
+// For the void elements or if there is no closure, it is equal to ContentIndex.
+func (t *Tag) contentEnd() int {
+	if t.AfterClosureIndex <= t.ContentIndex {
+		return t.ContentIndex
+	}
 
-	// return content between those tags
-	return t.doc[t.ContentIndex : t.ContentIndex+closureIndex]
+	return closingTagIndex(t.doc, t.Name, t.AfterClosureIndex)
+}
+
+// RawAttr returns the value of the attr attribute as it is written in doc, without replacing the character references.
+// The second value is false if the attribute does not exist.
+// attr is case-insensitive.
+func (t *Tag) RawAttr(attr string) (string, bool) {
+	if t == nil {
+		return "", false
+	}
+
+	// parse again the attributes between the tag's name and the closure of the opening tag
+	v, ok := parseRawAttribute(t.doc[t.StartIndex+1+len(t.RawName) : t.ContentIndex-1])[toLowerASCII(attr)]
+
+	return v, ok
+}
+
+// Classes returns the classes of t, i.e. the value of the class attribute split on white spaces, without duplicates.
+func (t *Tag) Classes() []string {
+	if t == nil {
+		return nil
+	}
+
+	var classes []string
+	seen := make(map[string]bool)
+	for _, c := range splitSpace(t.Attr["class"]) {
+		if !seen[c] {
+			seen[c] = true
+			classes = append(classes, c)
+		}
+	}
+
+	return classes
 }
 
 // Return the next *Tag with the same name and check functions
@@ -45,64 +128,293 @@ func (t *Tag) Next() *Tag {
 		return nil
 	}
 
-	newT := Find(t.doc[t.ContentIndex:], t.Name, t.checks)
-	if newT == nil {
+	q := t.query()
+	return find(newTokenizer(t.doc[:q.limit()], t.ContentIndex, t.Name), q)
+}
+
+// NextAfter returns the next *Tag with the same name and check functions which starts after t ends,
+// so the tags nested in t are skipped, e.g. the inner lists of a nested <ul>.
+// Returns nil if t has no closure.
+func (t *Tag) NextAfter() *Tag {
+	if t == nil || t.AfterClosureIndex == -1 {
+		return nil
+	}
+
+	q := t.query()
+	if t.AfterClosureIndex > q.limit() {
 		return nil
 	}
 
-	// change the starting point of the doc
-	newT.doc = t.doc
-	newT.ContentIndex += t.ContentIndex
-	newT.AfterClosureIndex += t.ContentIndex
+	return find(newTokenizer(t.doc[:q.limit()], t.AfterClosureIndex, ""), q)
+}
+
+// Prev returns the previous *Tag with the same name and check functions, i.e. the last one which starts before t.
+// It walks the tree of t's document, which is parsed at the first call and shared with the returned tags.
+func (t *Tag) Prev() *Tag {
+	if t == nil {
+		return nil
+	}
+
+	// walk backwards over the elements of the document, which are sorted by their starts
+	elements := t.document().elements
+	q := t.query()
+	i := sort.Search(len(elements), func(i int) bool {
+		return elements[i].Start >= t.StartIndex
+	})
+	for i--; i >= 0 && elements[i].Start >= q.begin; i-- {
+		if !q.matches(elements[i].Name) {
+			continue
+		}
+
+		if p := q.element(elements[i]); passChecks(q.checks, p) {
+			return p
+		}
+	}
+
+	return nil
+}
+
+// Find returns a *Tag struct representing a tag found in the content of t, which has the n name and satisfies all f functions.
+// Unlike Find(t.Content(), n, f), the indexes of the returned tag point to the document of t, and its Next method does not search beyond the content of t.
+// n is case-insensitive.
+func (t *Tag) Find(n string, f []Check) *Tag {
+	if t == nil {
+		return nil
+	}
+
+	q := t.contentQuery(n, f)
+	return find(newTokenizer(t.doc[:q.end], t.ContentIndex, t.Name), q)
+}
+
+// FindAll returns all tags found in the content of t, which have the n name and satisfy all f functions, in the document order.
+// The indexes of the returned tags point to the document of t.
+// n is case-insensitive.
+func (t *Tag) FindAll(n string, f []Check) []*Tag {
+	var tags []*Tag
+	if t == nil {
+		return tags
+	}
+
+	q := t.contentQuery(n, f)
+	each(newTokenizer(t.doc[:q.end], t.ContentIndex, t.Name), q, func(c *Tag) bool {
+		tags = append(tags, c)
+		return true
+	})
+
+	return tags
+}
+
+// query returns the query which finds t
+func (t *Tag) query() query {
+	names := t.names
+	if names == nil {
+		names = []string{t.Name}
+	}
+
+	return query{doc: t.doc, tree: t.tree, names: names, checks: t.checks, begin: t.begin, end: t.end}
+}
+
+// contentQuery returns the query of the tags in the content of t, which have the n name and satisfy all f functions
+func (t *Tag) contentQuery(n string, f []Check) query {
+	end := t.contentEnd()
+	if t.AfterClosureIndex == -1 {
+		// the content of the tag without a closure lasts until the end of doc
+		end = len(t.doc)
+	}
 
-	return newT
+	return query{doc: t.doc, tree: t.tree, names: []string{toLowerASCII(n)}, checks: f, begin: t.ContentIndex, end: end}
 }
 
 // Find returns a *Tag struct representing a tag found in the s string, which has the n name and satisfies all f functions.
+// n is case-insensitive; "*" matches any tag.
 func Find(s string, n string, f []Check) *Tag {
-	// starting at the beginning of s
-	pos := 0
+	return find(newTokenizer(s, 0, ""), query{doc: s, tree: new(docTree), names: []string{toLowerASCII(n)}, checks: f})
+}
+
+// FindAny returns a *Tag struct representing the first tag found in the s string, which has any of the names and satisfies all f functions,
+// e.g. FindAny(s, []string{"h1", "h2", "h3"}, nil). Next called on the returned tag continues with all the names.
+// The names are case-insensitive; "*" matches any tag.
+func FindAny(s string, names []string, f []Check) *Tag {
+	lower := make([]string, len(names))
+	for i, n := range names {
+		lower[i] = toLowerASCII(n)
+	}
+
+	return find(newTokenizer(s, 0, ""), query{doc: s, tree: new(docTree), names: lower, checks: f})
+}
+
+// FindLast returns a *Tag struct representing the last tag found in the s string, which has the n name and satisfies all f functions.
+// It is the same tag as the last one returned by the Find and Next loop; Prev called on it returns the previous one.
+// n is case-insensitive.
+func FindLast(s string, n string, f []Check) *Tag {
+	return findLast(newTokenizer(s, 0, ""), query{doc: s, tree: new(docTree), names: []string{toLowerASCII(n)}, checks: f})
+}
+
+// FindAll returns all tags found in the s string, which have the n name and satisfy all f functions, in the document order.
+// The result is the same as the one of the Find and Next loop, but s is scanned once.
+// n is case-insensitive.
+func FindAll(s string, n string, f []Check) []*Tag {
+	return FindN(s, n, f, -1)
+}
+
+// FindN is like FindAll, but returns at most limit tags. If limit is negative, all tags are returned.
+func FindN(s string, n string, f []Check, limit int) []*Tag {
+	var tags []*Tag
+	if limit == 0 {
+		return tags
+	}
+
+	Each(s, n, f, func(t *Tag) bool {
+		tags = append(tags, t)
+		return len(tags) != limit
+	})
 
-loop:
-	// as far as the end of s is not reached
-	for pos < len(s)-1 {
+	return tags
+}
+
+// Each calls fn for every tag found in the s string, which has the n name and satisfies all f functions, in the document order.
+// The iteration stops when fn returns false.
+// n is case-insensitive.
+func Each(s string, n string, f []Check, fn func(*Tag) bool) {
+	each(newTokenizer(s, 0, ""), query{doc: s, tree: new(docTree), names: []string{toLowerASCII(n)}, checks: f}, fn)
+}
+
+// query describes the tags to be found in a document.
+type query struct {
+	doc    string   // The document.
+	tree   *docTree // The tree of doc, which is shared with the found tags.
+	names  []string // The lowercase names of the tags; "*" matches any tag.
+	checks []Check  // The functions which the tags satisfy.
+	begin  int      // The index of doc where Prev of the found tags stops searching.
+	end    int      // The index of doc where Next of the found tags stops searching; 0 for the end of doc.
+}
+
+// limit returns the index of doc where the search ends
+func (q query) limit() int {
+	if q.end == 0 {
+		return len(q.doc)
+	}
 
-		//search for the start and end positions
-		start, end := findStartTag(s[pos:], n)
-		if start == -1 {
+	return q.end
+}
+
+// matches checks if the tag named n is searched for
+func (q query) matches(n string) bool {
+	for _, m := range q.names {
+		if m == n || m == "*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// each calls fn for every tag of the q query found by z, until fn returns false
+func each(z *Tokenizer, q query, fn func(*Tag) bool) {
+	// the tokenizer continues after the opening tag of the last found tag, which is where Next starts
+	for t := find(z, q); t != nil && fn(t); t = find(z, q) {
+	}
+}
+
+// find returns a *Tag struct representing the first tag of the q query found by z
+func find(z *Tokenizer, q query) *Tag {
+	// until the end of the document is reached
+	for {
+		tok, attr, err := z.next()
+		if err != nil {
 			// no such tag
 			return nil
 		}
 
-		// set the positions in relation to s
-		start += pos
-		end += pos
-
-		// create a tag for f checks
-		t := &Tag{
-			Name:              n,
-			Attr:              parseAttribute(s[start+len(n)+1 : end-1]),
-			ContentIndex:      end,
-			AfterClosureIndex: getAfterClosureIndex(s, n, end),
-			doc:               s,
-			checks:            f,
+		if tok.Type != StartTagToken || !q.matches(tok.Name) {
+			// continue after the current token
+			continue
+		}
+
+		// check if the tag will pass all checks
+		if t := q.tag(tok, attr); passChecks(q.checks, t) {
+			// return a found tag
+			return t
 		}
+	}
+}
 
-		// check if t will pass all f
-		if !passChecks(f, t) {
-			// continue after the current tag if checks failed
-			pos = end
-			continue loop
+// findLast returns a *Tag struct representing the last tag of the q query found by z
+func findLast(z *Tokenizer, q query) *Tag {
+	// collect the opening tags of the given name
+	type opening struct {
+		tok  Token
+		attr string
+	}
+	var tags []opening
+	for {
+		tok, attr, err := z.next()
+		if err != nil {
+			break
 		}
 
-		// return a found tag
-		return t
+		if tok.Type == StartTagToken && q.matches(tok.Name) {
+			tags = append(tags, opening{tok, attr})
+		}
+	}
+
+	// check the tags starting with the last one
+	for i := len(tags) - 1; i >= 0; i-- {
+		if t := q.tag(tags[i].tok, tags[i].attr); passChecks(q.checks, t) {
+			return t
+		}
 	}
 
 	// no such tag
 	return nil
 }
 
+// tag returns a *Tag struct representing the opening tag tok with the attributes attr
+func (q query) tag(tok Token, attr string) *Tag {
+	t := &Tag{
+		Name:              tok.Name,
+		RawName:           tok.Data[1 : 1+len(tok.Name)],
+		Attr:              parseAttribute(attr),
+		ContentIndex:      tok.End,
+		AfterClosureIndex: tok.End,
+		SelfClosing:       tok.SelfClosing,
+		StartIndex:        tok.Start,
+		doc:               q.doc,
+		checks:            q.checks,
+		tree:              q.tree,
+		begin:             q.begin,
+		end:               q.end,
+	}
+
+	if len(q.names) > 1 || q.names[0] == "*" {
+		// Next continues with all the names
+		t.names = q.names
+	}
+
+	// find the closing tag, unless the element is closed by the opening tag
+	if !closedByOpeningTag(tok.Name, tok.SelfClosing) {
+		t.AfterClosureIndex = getAfterClosureIndex(q.doc, tok.Name, tok.End)
+	}
+
+	return t
+}
+
+// element returns a *Tag struct representing the element n of the tree of doc
+func (q query) element(n *Node) *Tag {
+	t := n.Tag()
+	t.checks = q.checks
+	t.tree = q.tree
+	t.begin = q.begin
+	t.end = q.end
+
+	if len(q.names) > 1 || q.names[0] == "*" {
+		// Next continues with all the names
+		t.names = q.names
+	}
+
+	return t
+}
+
 // passChecks returns true if t pass all checks; returns false if not
 func passChecks(checks []Check, t *Tag) bool {
 	// loop over all checks
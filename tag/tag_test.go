@@ -2,6 +2,7 @@ package tag
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -19,24 +20,24 @@ func TestFind(t *testing.T) {
 		{
 			name: "1",
 			args: args{doc: `<some attr-1 = cont1 attr_2='cont2"' attr3="cont with space'">`, tag: "some"},
-			want: &Tag{Name: "some", Attr: map[string]string{"attr-1": "cont1", "attr_2": "cont2\"", "attr3": "cont with space'"}, ContentIndex: 62, AfterClosureIndex: -1},
+			want: &Tag{Name: "some", RawName: "some", Attr: map[string]string{"attr-1": "cont1", "attr_2": "cont2\"", "attr3": "cont with space'"}, ContentIndex: 62, AfterClosureIndex: -1},
 		},
 		{
 			name: "2",
 			args: args{doc: `<someother></someother><some attr1="cont1"
 			attr2="cont2"	attr3="cont
 			with	space"><someother>some text</someother>`, tag: "some"},
-			want: &Tag{Name: "some", Attr: map[string]string{"attr1": "cont1", "attr2": "cont2", "attr3": "cont\n\t\t\twith\tspace"}, ContentIndex: 87, AfterClosureIndex: -1},
+			want: &Tag{Name: "some", RawName: "some", Attr: map[string]string{"attr1": "cont1", "attr2": "cont2", "attr3": "cont\n\t\t\twith\tspace"}, ContentIndex: 87, AfterClosureIndex: -1, StartIndex: 23},
 		},
 		{
 			name: "3",
 			args: args{doc: `<some attr0 attr1="cont1" attr2="cont2" attr3="cont with space" attr4>`, tag: "some"},
-			want: &Tag{Name: "some", Attr: map[string]string{"attr0": "", "attr1": "cont1", "attr2": "cont2", "attr3": "cont with space", "attr4": ""}, ContentIndex: 70, AfterClosureIndex: -1},
+			want: &Tag{Name: "some", RawName: "some", Attr: map[string]string{"attr0": "", "attr1": "cont1", "attr2": "cont2", "attr3": "cont with space", "attr4": ""}, ContentIndex: 70, AfterClosureIndex: -1},
 		},
 		{
 			name: "4",
 			args: args{doc: `<some></some><some attr1="cont1" attr2="cont2" attr3="cont with space">`, tag: "some", match: []Check{Has("attr2"), Contains("attr3", "with"), Equal("attr1", "cont1")}},
-			want: &Tag{Name: "some", Attr: map[string]string{"attr1": "cont1", "attr2": "cont2", "attr3": "cont with space"}, ContentIndex: 71, AfterClosureIndex: -1},
+			want: &Tag{Name: "some", RawName: "some", Attr: map[string]string{"attr1": "cont1", "attr2": "cont2", "attr3": "cont with space"}, ContentIndex: 71, AfterClosureIndex: -1, StartIndex: 13},
 		},
 		{
 			name: "5",
@@ -56,12 +57,12 @@ func TestFind(t *testing.T) {
 		{
 			name: "8",
 			args: args{doc: `<br/>`, tag: "br", match: []Check{}},
-			want: &Tag{Name: "br", Attr: map[string]string{"/": ""}, ContentIndex: 5, AfterClosureIndex: -1},
+			want: &Tag{Name: "br", RawName: "br", Attr: map[string]string{}, ContentIndex: 5, AfterClosureIndex: 5, SelfClosing: true},
 		},
 		{
 			name: "9",
 			args: args{doc: `<br />`, tag: "br", match: []Check{}},
-			want: &Tag{Name: "br", Attr: map[string]string{"/": ""}, ContentIndex: 6, AfterClosureIndex: -1},
+			want: &Tag{Name: "br", RawName: "br", Attr: map[string]string{}, ContentIndex: 6, AfterClosureIndex: 6, SelfClosing: true},
 		},
 		{
 			name: "10",
@@ -76,43 +77,84 @@ func TestFind(t *testing.T) {
 		{
 			name: "12",
 			args: args{doc: `<br = />`, tag: "br", match: []Check{}},
-			want: &Tag{Name: "br", Attr: map[string]string{}, ContentIndex: 8, AfterClosureIndex: -1},
+			want: &Tag{Name: "br", RawName: "br", Attr: map[string]string{}, ContentIndex: 8, AfterClosureIndex: 8, SelfClosing: true},
 		},
 		{
 			name: "13",
 			args: args{doc: `<br name">`, tag: "br", match: []Check{}},
-			want: &Tag{Name: "br", Attr: map[string]string{}, ContentIndex: 10, AfterClosureIndex: -1},
+			want: &Tag{Name: "br", RawName: "br", Attr: map[string]string{}, ContentIndex: 10, AfterClosureIndex: 10},
 		},
 		{
 			name: "14",
 			args: args{doc: `<br name=val=">`, tag: "br", match: []Check{}},
-			want: &Tag{Name: "br", Attr: map[string]string{}, ContentIndex: 15, AfterClosureIndex: -1},
+			want: &Tag{Name: "br", RawName: "br", Attr: map[string]string{}, ContentIndex: 15, AfterClosureIndex: 15},
 		},
 		{
 			name: "15",
 			args: args{doc: `<br name="val"/">`, tag: "br", match: []Check{}},
-			want: &Tag{Name: "br", Attr: map[string]string{}, ContentIndex: 17, AfterClosureIndex: -1},
+			want: &Tag{Name: "br", RawName: "br", Attr: map[string]string{}, ContentIndex: 17, AfterClosureIndex: 17},
 		},
 		{
 			name: "16",
 			args: args{doc: `<br name="val""">`, tag: "br", match: []Check{}},
-			want: &Tag{Name: "br", Attr: map[string]string{}, ContentIndex: 17, AfterClosureIndex: -1},
+			want: &Tag{Name: "br", RawName: "br", Attr: map[string]string{}, ContentIndex: 17, AfterClosureIndex: 17},
 		},
 		{
 			name: "17",
 			args: args{doc: `<some attr1="cont1" attr2="cont2" attr3="cont with space">`, tag: "some", match: []Check{NotEmpty("attr2")}},
-			want: &Tag{Name: "some", Attr: map[string]string{"attr1": "cont1", "attr2": "cont2", "attr3": "cont with space"}, ContentIndex: 58, AfterClosureIndex: -1},
+			want: &Tag{Name: "some", RawName: "some", Attr: map[string]string{"attr1": "cont1", "attr2": "cont2", "attr3": "cont with space"}, ContentIndex: 58, AfterClosureIndex: -1},
 		},
 		{
 			name: "18",
 			args: args{doc: `<some attr1="cont1" attr2 attr3="cont with space">`, tag: "some", match: []Check{NotEmpty("attr2")}},
 			want: nil,
 		},
+		{
+			name: "19",
+			args: args{doc: `<SOME attr1="cont1"><some></SOME></Some>`, tag: "Some", match: []Check{Has("attr1")}},
+			want: &Tag{Name: "some", RawName: "SOME", Attr: map[string]string{"attr1": "cont1"}, ContentIndex: 20, AfterClosureIndex: 40},
+		},
+		{
+			name: "20",
+			args: args{doc: `<a title="a > b" href='/x?a<b'>`, tag: "a"},
+			want: &Tag{Name: "a", RawName: "a", Attr: map[string]string{"title": "a > b", "href": "/x?a<b"}, ContentIndex: 31, AfterClosureIndex: -1},
+		},
+		{
+			name: "21",
+			args: args{doc: `<a title=">"></a title="</a>"><a>`, tag: "a", match: []Check{Has("title")}},
+			want: &Tag{Name: "a", RawName: "a", Attr: map[string]string{"title": ">"}, ContentIndex: 13, AfterClosureIndex: 30},
+		},
+		{
+			name: "22",
+			args: args{doc: `<a b="c"d=">">`, tag: "a"},
+			want: &Tag{Name: "a", RawName: "a", Attr: map[string]string{}, ContentIndex: 14, AfterClosureIndex: -1},
+		},
+		{
+			name: "23",
+			args: args{doc: `<a title="é" ALT=à>`, tag: "a"},
+			want: &Tag{Name: "a", RawName: "a", Attr: map[string]string{"title": "é", "alt": "à"}, ContentIndex: 21, AfterClosureIndex: -1},
+		},
+		{
+			name: "24",
+			args: args{doc: `<img src=/x/><a href=/>x</a><svg><path d="M0"/><path/></svg>`, tag: "a"},
+			want: &Tag{Name: "a", RawName: "a", Attr: map[string]string{"href": "/"}, ContentIndex: 23, AfterClosureIndex: 28, StartIndex: 13},
+		},
+		{
+			name: "25",
+			args: args{doc: `<div><path d="M0"/><path/></div>`, tag: "path"},
+			want: &Tag{Name: "path", RawName: "path", Attr: map[string]string{"d": "M0"}, ContentIndex: 19, AfterClosureIndex: 19, SelfClosing: true, StartIndex: 5},
+		},
+		{
+			name: "26",
+			args: args{doc: `<div/><p>text</p></div>`, tag: "div"},
+			want: &Tag{Name: "div", RawName: "div", Attr: map[string]string{}, ContentIndex: 6, AfterClosureIndex: 23, SelfClosing: true},
+		},
 	}
 	for i := range tests {
 		if tests[i].want != nil {
 			tests[i].want.checks = tests[i].args.match
 			tests[i].want.doc = tests[i].args.doc
+			tests[i].want.tree = new(docTree)
 		}
 	}
 	for _, tt := range tests {
@@ -143,6 +185,15 @@ func TestContent(t *testing.T) {
 		{"6", args{"<ab><a ></ab></a >", "a", []Check{}}, "</ab>"},
 		{"7", args{"<ab><a ></ab></a", "a", []Check{}}, ""},
 		{"8", args{"<ab><a><a><ab></ab></ab></a ></ab>", "a", []Check{}}, ""},
+		{"9", args{"<DIV><div></DIV></Div>", "div", []Check{}}, "<div></DIV>"},
+		{"10", args{"<div><!-- <div> --></div>", "div", []Check{}}, "<!-- <div> -->"},
+		{"11", args{"<div><![CDATA[ </div> ]]></div>", "div", []Check{}}, "<![CDATA[ </div> ]]>"},
+		{"12", args{"<div><script>if (a</div>) {}</script></div>", "div", []Check{}}, "<script>if (a</div>) {}</script>"},
+		{"13", args{"<script><script></script>", "script", []Check{}}, "<script>"},
+		{"14", args{"<title><b>a</title></b>", "title", []Check{}}, "<b>a"},
+		{"15", args{"<a>x<style></a></style></a>", "a", []Check{}}, "x<style></a></style>"},
+		{"16", args{"<img><p>x</p></img>", "img", []Check{}}, ""},
+		{"17", args{"<ul><ul/><li/></ul></ul>", "ul", []Check{}}, "<ul/><li/></ul>"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -160,6 +211,102 @@ func TestContent(t *testing.T) {
 	})
 }
 
+func TestTag_RawAttr(t *testing.T) {
+	a := Find(`<p><a HREF="?a=1&amp;b=2" title=&lt;x&gt;>`, "a", nil)
+	if a.Attr["href"] != "?a=1&b=2" || a.Attr["title"] != "<x>" {
+		t.Errorf("Find().Attr = %v, want unescaped values", a.Attr)
+	}
+
+	tests := []struct {
+		attr   string
+		want   string
+		wantOk bool
+	}{
+		{"href", "?a=1&amp;b=2", true},
+		{"Title", "&lt;x&gt;", true},
+		{"id", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.attr, func(t *testing.T) {
+			got, ok := a.RawAttr(tt.attr)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("Tag.RawAttr() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+
+	t.Run("nil", func(t *testing.T) {
+		var tag *Tag
+		if v, ok := tag.RawAttr("href"); v != "" || ok {
+			t.Errorf("Tag.RawAttr() = %v, %v, want %v, %v", v, ok, "", false)
+		}
+	})
+}
+
+func TestFind_impliedEnd(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		tag  string
+		want []string
+	}{
+		{"li", `<ul><li>One<li>Two</ul><li>Three`, "li", []string{"One", "Two", "Three"}},
+		{"nested li", `<ul><li>a<ul><li>b</ul><li>c</li></ul>`, "li", []string{"a<ul><li>b</ul>", "b", "c"}},
+		{"li in div", `<ul><li>a<div><li>b</div></ul>`, "li", []string{"a<div>", "b</div>"}},
+		{"p", `<div><p>a<span>b<p>c</div><p>d<ul></ul><p>e</P>`, "p", []string{"a<span>b", "c", "d", "e"}},
+		{"dt dd", `<dl><dt>A<dd>B<dt>C<dd>D</dl>`, "dd", []string{"B", "D"}},
+		{"td", `<table><tr><td>1<th>2<tr><td>3</table>`, "td", []string{"1", "3"}},
+		{"tr", `<table><tr><td>1<td>2<tr><td>3</table>`, "tr", []string{"<td>1<td>2", "<td>3"}},
+		{"thead", `<table><thead><tr><th>h<tbody><tr><td>d</table>`, "thead", []string{"<tr><th>h"}},
+		{"nested table", `<table><tr><td><table><tr><td>a</table>b<td>c</table>`, "td", []string{"<table><tr><td>a</table>b", "a", "c"}},
+		{"option", `<select><optgroup><option>A<option>B<optgroup><option>C</select>`, "option", []string{"A", "B", "C"}},
+		{"optgroup", `<select><optgroup><option>A<optgroup><option>B</select>`, "optgroup", []string{"<option>A", "<option>B"}},
+		{"ruby", `<ruby><rb>a<rb>b<rt>c<rp>(<rtc>d</ruby>`, "rb", []string{"a", "b"}},
+		{"rt", `<ruby><rb>a<rt>c<rp>(<rtc><rt>d</ruby>`, "rt", []string{"c", "d"}},
+		{"not optional", `<ul><a>x<li>y</ul>`, "a", []string{""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for a := Find(tt.doc, tt.tag, nil); a != nil; a = a.Next() {
+				got = append(got, a.Content())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Find().Content() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFind_opaque(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		tag  string
+		want []int
+	}{
+		{"comment", `<!-- <a href="1"> --><a href="2">`, "a", []int{33}},
+		{"cdata", `<![CDATA[<a href="1">]]><a href="2">`, "a", []int{36}},
+		{"script", `<script>document.write("<a href='1'>")</script><a href="2">`, "a", []int{59}},
+		{"style", `<style>a:after { content: "<a>" }</style><a>`, "a", []int{44}},
+		{"textarea", `<textarea><a></textarea ><a>`, "a", []int{28}},
+		{"title", `<TITLE><a></Title><a>`, "a", []int{21}},
+		{"plaintext", `<a><plaintext><a></plaintext><a>`, "a", []int{3}},
+		{"scripts", `<script>"<script>"</script><script></script>`, "script", []int{8, 35}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			for a := Find(tt.doc, tt.tag, nil); a != nil; a = a.Next() {
+				got = append(got, a.ContentIndex)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Find().Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestTag_Next(t *testing.T) {
 	tests := []struct {
 		name string
@@ -169,49 +316,83 @@ func TestTag_Next(t *testing.T) {
 		{
 			"1",
 			&Tag{
-				"a",
-				map[string]string{"id": "1"},
-				8,
-				28,
-				"<a id=1><a id=2><a id=3></a></a></a>",
-				[]Check{Has("id")},
+				Name:              "a",
+				RawName:           "a",
+				Attr:              map[string]string{"id": "1"},
+				ContentIndex:      8,
+				AfterClosureIndex: 28,
+				StartIndex:        0,
+				doc:               "<a id=1><a id=2><a id=3></a></a></a>",
+				checks:            []Check{Has("id")},
+			},
+			&Tag{
+				Name:              "a",
+				RawName:           "a",
+				Attr:              map[string]string{"id": "2"},
+				ContentIndex:      16,
+				AfterClosureIndex: 32,
+				StartIndex:        8,
+				doc:               "<a id=1><a id=2><a id=3></a></a></a>",
 			},
-			&Tag{"a",
-				map[string]string{"id": "2"},
-				16,
-				32,
-				"<a id=1><a id=2><a id=3></a></a></a>",
-				[]Check{Has("id")}},
 		},
 		{
 			"2",
 			&Tag{
-				"a",
-				map[string]string{"id": "1"},
-				8,
-				32,
-				"<a id=1><br><a id=2><a id=3></a></a></a>",
-				[]Check{Has("id")},
+				Name:              "a",
+				RawName:           "a",
+				Attr:              map[string]string{"id": "1"},
+				ContentIndex:      8,
+				AfterClosureIndex: 32,
+				StartIndex:        0,
+				doc:               "<a id=1><br><a id=2><a id=3></a></a></a>",
+				checks:            []Check{Has("id")},
+			},
+			&Tag{
+				Name:              "a",
+				RawName:           "a",
+				Attr:              map[string]string{"id": "2"},
+				ContentIndex:      20,
+				AfterClosureIndex: 36,
+				StartIndex:        12,
+				doc:               "<a id=1><br><a id=2><a id=3></a></a></a>",
 			},
-			&Tag{"a",
-				map[string]string{"id": "2"},
-				20,
-				36,
-				"<a id=1><br><a id=2><a id=3></a></a></a>",
-				[]Check{Has("id")}},
 		},
 		{
 			"3",
 			&Tag{
-				"a",
-				map[string]string{"id": "3"},
-				28,
-				40,
-				"<a id=1><br><a id=2><a id=3></a></a></a>",
-				[]Check{Has("id")},
+				Name:              "a",
+				RawName:           "a",
+				Attr:              map[string]string{"id": "3"},
+				ContentIndex:      28,
+				AfterClosureIndex: 40,
+				StartIndex:        20,
+				doc:               "<a id=1><br><a id=2><a id=3></a></a></a>",
+				checks:            []Check{Has("id")},
 			},
 			nil,
 		},
+		{
+			"4",
+			&Tag{
+				Name:              "a",
+				RawName:           "a",
+				Attr:              map[string]string{"id": "1"},
+				ContentIndex:      8,
+				AfterClosureIndex: 36,
+				StartIndex:        0,
+				doc:               "<a id=1><A id=2><a id=3></a></A></a>",
+				checks:            []Check{Has("id")},
+			},
+			&Tag{
+				Name:              "a",
+				RawName:           "A",
+				Attr:              map[string]string{"id": "2"},
+				ContentIndex:      16,
+				AfterClosureIndex: 32,
+				StartIndex:        8,
+				doc:               "<a id=1><A id=2><a id=3></a></A></a>",
+			},
+		},
 	}
 	for i := range tests {
 		if tests[i].want != nil {
@@ -234,3 +415,304 @@ func TestTag_Next(t *testing.T) {
 		}
 	})
 }
+
+func TestFindAll(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		n    string
+		f    []Check
+	}{
+		{"1", "<a id=1><a id=2><a id=3></a></a></a>", "a", []Check{Has("id")}},
+		{"2", "<ul><li>1<li id=x>2<LI>3</ul><!-- <li> --><li>4", "li", nil},
+		{"3", "<div><script><div></script><div class=b></div></div>", "DIV", []Check{Has("class")}},
+		{"4", "<p>no tags", "a", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the result is the same as the one of the Find and Next loop
+			var want []*Tag
+			for tag := Find(tt.doc, tt.n, tt.f); tag != nil; tag = tag.Next() {
+				want = append(want, tag)
+			}
+
+			if got := FindAll(tt.doc, tt.n, tt.f); !reflect.DeepEqual(got, want) {
+				t.Errorf("FindAll() = %v, want %v", got, want)
+			}
+
+			for limit := 0; limit <= len(want)+1; limit++ {
+				w := want
+				if limit < len(want) {
+					w = want[:limit]
+				}
+				if got := FindN(tt.doc, tt.n, tt.f, limit); len(got) != len(w) || (len(w) > 0 && !reflect.DeepEqual(got, w)) {
+					t.Errorf("FindN(%d) = %v, want %v", limit, got, w)
+				}
+			}
+
+			// Each stops when the function returns false
+			var got []*Tag
+			Each(tt.doc, tt.n, tt.f, func(tag *Tag) bool {
+				got = append(got, tag)
+				return false
+			})
+			w := want
+			if len(w) > 1 {
+				w = w[:1]
+			}
+			if len(got) != len(w) || (len(w) > 0 && !reflect.DeepEqual(got, w)) {
+				t.Errorf("Each() = %v, want %v", got, w)
+			}
+		})
+	}
+}
+
+func TestTag_Classes(t *testing.T) {
+	tests := []struct {
+		name string
+		arg  *Tag
+		want []string
+	}{
+		{"1", &Tag{Attr: map[string]string{"class": " a\tb\n\fa  c\r"}}, []string{"a", "b", "c"}},
+		{"2", &Tag{Attr: map[string]string{"class": "a\u00a0b"}}, []string{"a\u00a0b"}},
+		{"3", &Tag{Attr: map[string]string{"class": "  "}}, nil},
+		{"4", &Tag{Attr: map[string]string{}}, nil},
+		{"5", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.arg.Classes(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Tag.Classes() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTag_Find(t *testing.T) {
+	doc := `<a id=0></a><div id=d><a id=1>1</a><p><a id=2>2</a><div id=i><a id=3>3</a></div></div><a id=4>4</a><ul><li id=l><a id=5>5</a><li><a id=6>6</a></ul><script><a id=7></script>`
+
+	div := Find(doc, "div", nil)
+	a := div.Find("A", []Check{Has("id")})
+	if a == nil || a.Attr["id"] != "1" {
+		t.Fatalf("Tag.Find() = %v, want id 1", a)
+	}
+	if got := doc[a.StartIndex:a.AfterClosureIndex]; got != "<a id=1>1</a>" {
+		t.Errorf("Tag.Find() indexes point to %q", got)
+	}
+
+	// Next stops at the closing tag of the parent
+	var ids []string
+	for ; a != nil; a = a.Next() {
+		ids = append(ids, a.Attr["id"])
+	}
+	if got := strings.Join(ids, " "); got != "1 2 3" {
+		t.Errorf("Tag.Find().Next() ids = %q, want %q", got, "1 2 3")
+	}
+
+	tests := []struct {
+		name string
+		arg  *Tag
+		n    string
+		want string
+	}{
+		{"1", div, "a", "1 2 3"},
+		{"2", div, "div", "i"},
+		{"3", div.Find("p", nil), "a", "2"},
+		{"4", Find(doc, "li", nil), "a", "5"},
+		{"5", Find(doc, "ul", nil), "a", "5 6"},
+		{"6", Find(doc, "script", nil), "a", ""},
+		{"7", Find(doc, "a", nil), "a", ""},
+		{"8", nil, "a", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ids []string
+			tags := tt.arg.FindAll(tt.n, nil)
+			for _, tag := range tags {
+				ids = append(ids, tag.Attr["id"])
+			}
+			if got := strings.Join(ids, " "); got != tt.want {
+				t.Errorf("Tag.FindAll() ids = %q, want %q", got, tt.want)
+			}
+
+			// the indexes are the same as for the tags found in the whole document
+			for _, tag := range tags {
+				w := Find(doc, tt.n, []Check{Equal("id", tag.Attr["id"])})
+				if tag.StartIndex != w.StartIndex || tag.ContentIndex != w.ContentIndex || tag.AfterClosureIndex != w.AfterClosureIndex {
+					t.Errorf("Tag.FindAll() = %v, want %v", tag, w)
+				}
+			}
+		})
+	}
+
+	// the content of a tag without a closure lasts until the end of the document
+	section := Find(`<section><a id=8>8</a><b><a id=9>`, "section", nil)
+	if got := section.FindAll("a", nil); len(got) != 2 || got[0].Attr["id"] != "8" || got[1].Attr["id"] != "9" {
+		t.Errorf("Tag.FindAll() = %v, want ids 8 and 9", got)
+	}
+	if got := section.Find("a", nil).Next(); got == nil || got.Attr["id"] != "9" {
+		t.Errorf("Tag.Find().Next() = %v, want id 9", got)
+	}
+}
+
+func TestTag_Prev(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		n    string
+		f    []Check
+	}{
+		{"1", "<a id=1><a id=2><a id=3></a></a></a>", "a", []Check{Has("id")}},
+		{"2", "<ul><li>1<li id=x>2<LI>3</ul><!-- <li> --><li>4", "li", nil},
+		{"3", "<div><script><div></script><div class=b></div></div><div class=c>", "DIV", []Check{Has("class")}},
+		{"4", "<p>no tags", "a", nil},
+		{"5", "<nav><a href=/1>1</a><a>x</a><a href=/2>2</a></nav>", "a", []Check{Has("href")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := FindAll(tt.doc, tt.n, tt.f)
+
+			// the Prev loop returns the tags of the Find and Next loop in the reverse order
+			var got []*Tag
+			for tag := FindLast(tt.doc, tt.n, tt.f); tag != nil; tag = tag.Prev() {
+				got = append([]*Tag{tag}, got...)
+			}
+			// unlike FindAll, Prev walks the tree of the document
+			for _, tag := range append(got, want...) {
+				tag.tree = nil
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("FindLast().Prev() = %v, want %v", got, want)
+			}
+		})
+	}
+
+	// Prev does not search before the content of the parent
+	doc := `<a id=0></a><div><a id=1></a><a id=2></a></div>`
+	a := Find(doc, "div", nil).FindAll("a", nil)[1]
+	if got := ids(a.Prev(), a.Prev().Prev()); got != "1 nil" {
+		t.Errorf("Tag.Prev() ids = %q, want %q", got, "1 nil")
+	}
+}
+
+func TestTag_NextAfter(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		n    string
+		want string
+	}{
+		{"1", "<ul id=1><li><ul id=2><li></ul></ul><ul id=3><ul id=4></ul></ul>", "ul", "1 3"},
+		{"2", "<div id=1><div id=2></div></div><p><div id=3></div>", "div", "1 3"},
+		{"3", "<ul><li id=1><ul><li id=2></ul><li id=3><li id=4></ul>", "li", "1 3 4"},
+		{"4", "<b id=1><i id=2></i></b><b id=3></b><b id=4>", "b", "1 3 4"},
+		{"5", "<div id=1><div id=2>", "div", "1"},
+		{"6", "<br id=1><br id=2>", "br", "1 2"},
+		{"7", "<script id=1><script id=2></script><script id=3></script>", "script", "1 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tags []*Tag
+			for tag := Find(tt.doc, tt.n, nil); tag != nil; tag = tag.NextAfter() {
+				tags = append(tags, tag)
+			}
+			if got := ids(tags...); got != tt.want {
+				t.Errorf("Tag.NextAfter() ids = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// NextAfter does not search beyond the content of the parent
+	doc := `<div><p id=1><p id=2></div><p id=3>`
+	if got := ids(Find(doc, "div", nil).Find("p", nil).NextAfter().NextAfter()); got != "nil" {
+		t.Errorf("Tag.NextAfter() ids = %q, want %q", got, "nil")
+	}
+}
+
+func TestFindAny(t *testing.T) {
+	doc := `<h2 id=1>A</h2><p id=2 data-id=x><H1 id=3>B</H1><!-- <h3 id=c> --><div id=4 data-id=y><h3 id=5>C</h3></div><h4 id=6>`
+
+	tests := []struct {
+		name  string
+		names []string
+		f     []Check
+		want  string
+	}{
+		{"1", []string{"h1", "h2", "H3"}, nil, "1 3 5"},
+		{"2", []string{"*"}, []Check{Has("data-id")}, "2 4"},
+		{"3", []string{"*"}, nil, "1 2 3 4 5 6"},
+		{"4", []string{"div", "p"}, nil, "2 4"},
+		{"5", []string{"h5"}, nil, ""},
+		{"6", nil, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tags []*Tag
+			for tag := FindAny(doc, tt.names, tt.f); tag != nil; tag = tag.Next() {
+				tags = append(tags, tag)
+			}
+			if got := ids(tags...); got != tt.want {
+				t.Errorf("FindAny().Next() ids = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// the wildcard works with the other functions
+	if got := ids(FindAll(doc, "*", []Check{Has("data-id")})...); got != "2 4" {
+		t.Errorf("FindAll() ids = %q, want %q", got, "2 4")
+	}
+	if got := ids(FindLast(doc, "*", nil).Prev()); got != "5" {
+		t.Errorf("FindLast().Prev() ids = %q, want %q", got, "5")
+	}
+	if got := ids(Find(doc, "div", nil).FindAll("*", nil)...); got != "5" {
+		t.Errorf("Tag.FindAll() ids = %q, want %q", got, "5")
+	}
+
+	tag := Find(doc, "*", []Check{Equal("id", "3")})
+	if tag.Name != "h1" || tag.RawName != "H1" || tag.Content() != "B" {
+		t.Errorf("Find() = %v, want h1", tag)
+	}
+}
+
+func TestTag_source(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		n     string
+		outer string
+		start string
+		end   string
+		inner string
+	}{
+		{"1", `x<a href="/">A <b>b</b></A >y`, "a", `<a href="/">A <b>b</b></A >`, `<a href="/">`, `</A >`, "A <b>b</b>"},
+		{"2", `<ul><li class=a>One<li>Two</ul>`, "li", `<li class=a>One`, `<li class=a>`, "", "One"},
+		{"3", `<p>x<br/>y</p>`, "br", `<br/>`, `<br/>`, "", ""},
+		{"4", `<div id=1><span>`, "div", `<div id=1>`, `<div id=1>`, "", ""},
+		{"5", `<script>if (a</b) {}</script>`, "script", `<script>if (a</b) {}</script>`, `<script>`, `</script>`, "if (a</b) {}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tag := Find(tt.doc, tt.n, nil)
+			if got := tag.OuterHTML(); got != tt.outer {
+				t.Errorf("Tag.OuterHTML() = %q, want %q", got, tt.outer)
+			}
+			if got := tag.StartTag(); got != tt.start {
+				t.Errorf("Tag.StartTag() = %q, want %q", got, tt.start)
+			}
+			if got := tag.EndTag(); got != tt.end {
+				t.Errorf("Tag.EndTag() = %q, want %q", got, tt.end)
+			}
+			if got := tag.InnerHTML(); got != tt.inner {
+				t.Errorf("Tag.InnerHTML() = %q, want %q", got, tt.inner)
+			}
+			if got := tt.doc[tag.StartIndex:tag.ContentIndex]; got != tt.start {
+				t.Errorf("Tag.StartIndex points to %q, want %q", got, tt.start)
+			}
+		})
+	}
+
+	var tag *Tag
+	if tag.OuterHTML() != "" || tag.StartTag() != "" || tag.EndTag() != "" || tag.InnerHTML() != "" {
+		t.Errorf("nil Tag returns a source")
+	}
+}
@@ -0,0 +1,195 @@
+package tag
+
+import "strings"
+
+// Text returns the text of t's content as a browser would display it: without the markup, with character references replaced,
+// without the content of script, style and template elements, and with white spaces collapsed to a single space,
+// except in pre and textarea. The text of block elements, e.g. div, p or li, starts and ends on a new line,
+// <br> breaks a line, and table cells are separated by tabs.
+func (t *Tag) Text() string {
+	if t == nil || t.AfterClosureIndex <= t.ContentIndex {
+		return ""
+	}
+
+	var w textWriter
+
+	// the content of t might be a raw text, e.g. of title
+	z := newTokenizer(t.doc[:t.contentEnd()], t.ContentIndex, t.Name)
+	raw := ""
+	if rawTextElements[t.Name] {
+		raw = t.Name
+	}
+	pre := 0
+	if preformattedElements[t.Name] {
+		pre = 1
+		// a new line at the beginning of pre is ignored
+		w.skipNewLine = true
+	}
+	// the depth of the template elements
+	hidden := 0
+
+	for {
+		tok, _, err := z.next()
+		if err != nil {
+			break
+		}
+
+		// the text token following the opening tag of a raw text element is its content
+		r := raw
+		raw = ""
+
+		switch tok.Type {
+		case TextToken:
+			if hidden > 0 || r == "script" || r == "style" {
+				continue
+			}
+
+			s := tok.Data
+			if r == "" || r == "title" || r == "textarea" {
+				s = unescape(s, false)
+			}
+			if pre > 0 {
+				w.writePre(s)
+			} else {
+				w.write(s)
+			}
+		case CDATAToken:
+			if hidden == 0 {
+				w.write(strings.TrimSuffix(tok.Data[len("<![CDATA["):], "]]>"))
+			}
+		case StartTagToken:
+			if rawTextElements[tok.Name] {
+				raw = tok.Name
+			}
+			if tok.Name == "template" {
+				hidden++
+			}
+			if hidden > 0 {
+				continue
+			}
+
+			switch {
+			case tok.Name == "br":
+				w.lineBreak()
+			case tok.Name == "td" || tok.Name == "th":
+				w.tab = true
+			case blockElements[tok.Name]:
+				w.block()
+			}
+			if preformattedElements[tok.Name] {
+				pre++
+				// a new line at the beginning of pre is ignored
+				w.skipNewLine = true
+			}
+		case EndTagToken:
+			if tok.Name == "template" && hidden > 0 {
+				hidden--
+				continue
+			}
+			if hidden > 0 {
+				continue
+			}
+
+			if blockElements[tok.Name] {
+				w.block()
+			}
+			if preformattedElements[tok.Name] && pre > 0 {
+				pre--
+			}
+		}
+	}
+
+	return w.b.String()
+}
+
+// textWriter builds the text of an element, collapsing white spaces and line breaks.
+type textWriter struct {
+	b           strings.Builder
+	space       bool // True if there is a white space before the next text.
+	tab         bool // True if there is a table cell before the next text.
+	breaks      int  // The number of new lines before the next text.
+	skipNewLine bool // True if a new line at the beginning of the next text is ignored.
+}
+
+// write writes s with white spaces collapsed
+func (w *textWriter) write(s string) {
+	w.skipNewLine = false
+	for len(s) > 0 {
+		// skip the white spaces
+		i := 0
+		for i < len(s) && isSpace(s[i]) {
+			i++
+		}
+		if i > 0 {
+			w.space = true
+			s = s[i:]
+			continue
+		}
+
+		// write a word
+		for i < len(s) && !isSpace(s[i]) {
+			i++
+		}
+		w.separate()
+		w.b.WriteString(s[:i])
+		s = s[i:]
+	}
+}
+
+// writePre writes s with white spaces preserved
+func (w *textWriter) writePre(s string) {
+	if w.skipNewLine {
+		s = strings.TrimPrefix(s, "\n")
+		w.skipNewLine = false
+	}
+	if s == "" {
+		return
+	}
+
+	w.separate()
+	w.b.WriteString(s)
+}
+
+// separate writes the separator between the written text and the next one; there is none at the beginning of the text
+func (w *textWriter) separate() {
+	if w.b.Len() > 0 {
+		switch {
+		case w.breaks > 0:
+			w.b.WriteString(strings.Repeat("\n", w.breaks))
+		case w.tab:
+			w.b.WriteByte('\t')
+		case w.space:
+			w.b.WriteByte(' ')
+		}
+	}
+
+	w.space, w.tab, w.breaks = false, false, 0
+}
+
+// block starts a new line, unless it is already started
+func (w *textWriter) block() {
+	if w.breaks == 0 {
+		w.breaks = 1
+	}
+}
+
+// lineBreak breaks a line
+func (w *textWriter) lineBreak() {
+	w.breaks++
+}
+
+// text returns the text of the content of t with all white spaces collapsed to a single space.
+// It is computed when it is needed for the first time.
+func (t *Tag) text() string {
+	if t.normalized == nil {
+		s := collapseSpace(t.Text())
+		t.normalized = &s
+	}
+
+	return *t.normalized
+}
+
+// collapseSpace returns s with leading and trailing white spaces removed and other sequences of white spaces replaced by a single space
+func collapseSpace(s string) string {
+	return strings.Join(splitSpace(s), " ")
+}
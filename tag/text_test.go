@@ -0,0 +1,41 @@
+package tag
+
+import "testing"
+
+func TestTag_Text(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{"1", "<div>\n  Hello,\n\t<b>world</b> !  </div>", "Hello, world !"},
+		{"2", "<div>a &amp; b&nbsp;&eacute;&#8217;</div>", "a & b é’"},
+		{"3", "<div><p>One</p><p>Two</p>Three</div>", "One\nTwo\nThree"},
+		{"4", "<div>a<br>b<br><br>c<br></div>", "a\nb\n\nc"},
+		{"5", "<div><ul>\n<li>1\n<li>2</ul></div>", "1\n2"},
+		{"6", "<div>a<script>var x = '<b>';</script>b<style>p{}</style><template><p>t</p></template>c</div>", "abc"},
+		{"7", "<div>x<pre>\n  a\n   b</pre>y</div>", "x\n  a\n   b\ny"},
+		{"8", "<div><table><tr><th>A<th>B<tr><td>1<td>2</table></div>", "A\tB\n1\t2"},
+		{"9", "<div><!-- c --><![CDATA[x]]>y<span> </span>z</div>", "xy z"},
+		{"10", "<div>a<i>b</i>c <i> d </i> e</div>", "abc d e"},
+		{"11", "<title> A &lt; B </title>", "A < B"},
+		{"12", "<textarea>\n a  &amp; b</textarea>", " a  & b"},
+		{"13", "<script>var x = 1;</script>", ""},
+		{"14", "<br>", ""},
+		{"15", "<div>", ""},
+		{"16", "<div><template><template></template>x</template>y</div>", "y"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tag := FindAny(tt.doc, []string{"*"}, nil)
+			if got := tag.Text(); got != tt.want {
+				t.Errorf("Tag.Text() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	var tag *Tag
+	if got := tag.Text(); got != "" {
+		t.Errorf("Tag.Text() = %q, want %q", got, "")
+	}
+}
@@ -0,0 +1,444 @@
+package tag
+
+import (
+	"io"
+	"strings"
+)
+
+// TokenType is a type of Token.
+type TokenType int
+
+const (
+	TextToken     TokenType = iota // A text between tags.
+	StartTagToken                  // An opening tag, e.g. <a href="/">.
+	EndTagToken                    // A closing tag, e.g. </a>.
+	CommentToken                   // A comment, e.g. <!-- text -->.
+	DoctypeToken                   // A document type declaration, e.g. <!DOCTYPE html>.
+	CDATAToken                     // A CDATA section, e.g. <![CDATA[ text ]]>.
+
+	ignoredToken TokenType = -1 // A markup which is dropped by the tokenizer, e.g. </>.
+)
+
+// String returns the name of tt.
+func (tt TokenType) String() string {
+	switch tt {
+	case TextToken:
+		return "Text"
+	case StartTagToken:
+		return "StartTag"
+	case EndTagToken:
+		return "EndTag"
+	case CommentToken:
+		return "Comment"
+	case DoctypeToken:
+		return "Doctype"
+	case CDATAToken:
+		return "CDATA"
+	}
+
+	return "Invalid"
+}
+
+// Token is a representation of a piece of an HTML document returned by Tokenizer.
+type Token struct {
+	Type        TokenType         // The type of the token.
+	Name        string            // The name of the tag in lowercase. Empty for tokens other than StartTagToken and EndTagToken.
+	Attr        map[string]string // The map of attributes map[attr_name]attr_val of StartTagToken. Nil for other tokens.
+	Data        string            // The token as it is written in the document.
+	SelfClosing bool              // True if StartTagToken is closed with "/>", e.g. <br/>.
+	Start       int               // The index of the first byte of the token in the document.
+	End         int               // The index of the next byte after the token in the document.
+}
+
+// rawTextElements is a set of elements whose content is a text which is not searched for tags.
+// It contains the raw text and escapable raw text elements, and the plaintext element.
+// The noscript element is not included, because its content is parsed as HTML when scripting is disabled.
+var rawTextElements = map[string]bool{
+	"script":    true,
+	"style":     true,
+	"xmp":       true,
+	"iframe":    true,
+	"noembed":   true,
+	"noframes":  true,
+	"textarea":  true,
+	"title":     true,
+	"plaintext": true,
+}
+
+// chunkSize is the minimal number of bytes read from io.Reader at once.
+const chunkSize = 4096
+
+// Tokenizer splits an HTML document read from io.Reader into tokens.
+// It keeps in memory only the part of the document which is needed to return the current token,
+// so the memory usage depends on the length of the longest token, not on the length of the document.
+type Tokenizer struct {
+	r      io.Reader // The source of the document. Nil if the whole document is already in buf.
+	buf    string    // The part of the document which has been read, but not yet tokenized.
+	offset int       // The index of buf[0] in the document.
+	err    error     // The error returned by r; io.EOF at the end of the document.
+	raw    string    // The name of the raw text element whose content is being tokenized.
+}
+
+// NewTokenizer returns a new Tokenizer reading the document from r.
+func NewTokenizer(r io.Reader) *Tokenizer {
+	return &Tokenizer{r: r}
+}
+
+// newTokenizer returns a new Tokenizer of the document s, starting at the index i.
+// n is the name of the opening tag which ends at i, or an empty string; it is needed to tokenize the content of raw text elements.
+// It does not copy s; all returned tokens are substrings of s.
+func newTokenizer(s string, i int, n string) *Tokenizer {
+	z := &Tokenizer{buf: s[i:], offset: i, err: io.EOF}
+	if rawTextElements[n] {
+		z.raw = n
+	}
+
+	return z
+}
+
+// Next returns the next token of the document.
+// It returns io.EOF at the end of the document, or any other error returned by the underlying io.Reader.
+func (z *Tokenizer) Next() (Token, error) {
+	tok, attr, err := z.next()
+	if err != nil {
+		return Token{}, err
+	}
+
+	if tok.Type == StartTagToken {
+		tok.Attr = parseAttribute(attr)
+	}
+
+	return tok, nil
+}
+
+// next returns the next token of the document without parsing its attributes.
+// attr is the part of StartTagToken between the tag's name and its closure.
+func (z *Tokenizer) next() (tok Token, attr string, err error) {
+	for {
+		var n int
+		if z.raw != "" {
+			// the content of a raw text element
+			tok, n = lexRawText(z.buf, z.raw, z.err == io.EOF)
+			if tok.Type == ignoredToken {
+				// the content is empty
+				z.raw = ""
+				continue
+			}
+		} else {
+			tok, attr, n = lex(z.buf, z.err == io.EOF)
+		}
+
+		if n == 0 {
+			// more data is needed to find the end of the token
+			if z.err != nil {
+				return Token{}, "", z.err
+			}
+
+			z.fill()
+			continue
+		}
+
+		// set the token's positions in relation to the document
+		tok.Data = z.buf[:n]
+		tok.Start = z.offset
+		tok.End = z.offset + n
+
+		// move after the token
+		z.buf = z.buf[n:]
+		z.offset += n
+
+		switch {
+		case z.raw != "":
+			// the raw text ends before its closing tag
+			z.raw = ""
+		case tok.Type == StartTagToken && rawTextElements[tok.Name]:
+			// the content of the tag is a raw text
+			z.raw = tok.Name
+		}
+
+		if tok.Type == ignoredToken {
+			continue
+		}
+
+		return tok, attr, nil
+	}
+}
+
+// fill reads the next part of the document into buf.
+func (z *Tokenizer) fill() {
+	if z.r == nil {
+		z.err = io.EOF
+		return
+	}
+
+	// read at least as many bytes as are already buffered to avoid copying a long token too many times
+	size := chunkSize
+	if len(z.buf) > size {
+		size = len(z.buf)
+	}
+
+	b := make([]byte, size)
+	n, err := z.r.Read(b)
+	z.buf += string(b[:n])
+	if err != nil {
+		z.err = err
+	}
+}
+
+// lex returns the first token of s and its length n.
+// If eof is false and s does not contain the whole token, n is 0.
+//
+// Loosely inspired by the algorithm described here:
+// https://html.spec.whatwg.org/multipage/parsing.html#data-state
+func lex(s string, eof bool) (tok Token, attr string, n int) {
+	if len(s) == 0 {
+		return tok, "", 0
+	}
+
+	if s[0] != '<' {
+		return lexText(s, 0, eof)
+	}
+
+	// the constructs starting with "<!" are recognized by the prefixes up to 9 characters long
+	if !eof && (len(s) < 2 || (s[1] == '!' && len(s) < 9) || (s[1] == '/' && len(s) < 3)) {
+		return tok, "", 0
+	}
+
+	switch {
+	case len(s) < 2:
+		// a single '<' at the end of the document
+		return lexText(s, 1, eof)
+	case strings.HasPrefix(s, "<!--"):
+		return lexComment(s, eof)
+	case strings.HasPrefix(s, "<![CDATA["):
+		return lexCDATA(s, eof)
+	case s[1] == '!':
+		if len(s) >= 9 && strings.EqualFold(s[2:9], "doctype") {
+			tok, attr, n = lexUntilClosure(s, eof)
+			tok.Type = DoctypeToken
+			return tok, attr, n
+		}
+		// bogus comment
+		return lexUntilClosure(s, eof)
+	case s[1] == '?':
+		// bogus comment
+		return lexUntilClosure(s, eof)
+	case s[1] == '/':
+		switch {
+		case len(s) < 3:
+			// "</" at the end of the document is a text
+			return lexText(s, 2, eof)
+		case isASCIILetter(s[2]):
+			return lexTag(s, 2, EndTagToken, eof)
+		case s[2] == '>':
+			// "</>" is ignored
+			tok.Type = ignoredToken
+			return tok, "", 3
+		}
+		// bogus comment
+		return lexUntilClosure(s, eof)
+	case isASCIILetter(s[1]):
+		return lexTag(s, 1, StartTagToken, eof)
+	}
+
+	// '<' which does not start a tag is a text
+	return lexText(s, 1, eof)
+}
+
+// lexText returns a text token which starts at the beginning of s and ends before the next tag, starting the search at the index i.
+func lexText(s string, i int, eof bool) (tok Token, attr string, n int) {
+	tok.Type = TextToken
+
+	for i < len(s) {
+		// find the next '<'
+		j := strings.IndexByte(s[i:], '<')
+		if j == -1 {
+			break
+		}
+		i += j
+
+		if i+1 == len(s) {
+			// the next character is needed to decide if it is the beginning of a tag
+			break
+		}
+
+		if c := s[i+1]; isASCIILetter(c) || c == '/' || c == '!' || c == '?' {
+			// the text ends before the tag
+			return tok, "", i
+		}
+
+		// the '<' is a part of the text
+		i++
+	}
+
+	if !eof {
+		// the text might be continued
+		return tok, "", 0
+	}
+
+	return tok, "", len(s)
+}
+
+// lexComment returns a comment token which starts at the beginning of s.
+func lexComment(s string, eof bool) (tok Token, attr string, n int) {
+	tok.Type = CommentToken
+
+	// "<!-->" and "<!--->" are empty comments
+	if strings.HasPrefix(s[4:], ">") {
+		return tok, "", 5
+	}
+	if strings.HasPrefix(s[4:], "->") {
+		return tok, "", 6
+	}
+
+	end := strings.Index(s[4:], "-->")
+	if end == -1 {
+		if !eof {
+			return tok, "", 0
+		}
+		// the comment lasts until the end of the document
+		return tok, "", len(s)
+	}
+
+	return tok, "", 4 + end + 3
+}
+
+// lexCDATA returns a CDATA token which starts at the beginning of s.
+func lexCDATA(s string, eof bool) (tok Token, attr string, n int) {
+	tok.Type = CDATAToken
+
+	end := strings.Index(s[9:], "]]>")
+	if end == -1 {
+		if !eof {
+			return tok, "", 0
+		}
+		// the section lasts until the end of the document
+		return tok, "", len(s)
+	}
+
+	return tok, "", 9 + end + 3
+}
+
+// lexRawText returns a text token which starts at the beginning of s and ends before the closing tag of the raw text element named n.
+// If the text is empty, the type of the returned token is ignoredToken.
+func lexRawText(s, n string, eof bool) (tok Token, i int) {
+	tok.Type = TextToken
+
+	if n == "plaintext" {
+		// the plaintext element lasts until the end of the document
+		if !eof {
+			return tok, 0
+		}
+		i = len(s)
+	}
+
+	for i < len(s) {
+		// find the next "</"
+		j := strings.Index(s[i:], "</")
+		if j == -1 {
+			i = len(s)
+			break
+		}
+		i += j
+
+		// the name needs to be followed by a white space, '/' or '>'
+		nameEnd := i + 2 + len(n)
+		if nameEnd >= len(s) {
+			if !eof {
+				return tok, 0
+			}
+			i = len(s)
+			break
+		}
+
+		if strings.EqualFold(s[i+2:nameEnd], n) && !isValidAttrNameChar(s[nameEnd]) {
+			// the text ends before the closing tag
+			break
+		}
+
+		// the "</" is a part of the text
+		i += 2
+	}
+
+	if i == len(s) && !eof {
+		// the text might be continued
+		return tok, 0
+	}
+
+	if i == 0 {
+		// there is no text before the closing tag
+		tok.Type = ignoredToken
+	}
+
+	return tok, i
+}
+
+// lexUntilClosure returns a comment token which starts at the beginning of s and ends after the nearest '>'.
+func lexUntilClosure(s string, eof bool) (tok Token, attr string, n int) {
+	tok.Type = CommentToken
+
+	end := strings.IndexByte(s, '>')
+	if end == -1 {
+		if !eof {
+			return tok, "", 0
+		}
+		// the comment lasts until the end of the document
+		return tok, "", len(s)
+	}
+
+	return tok, "", end + 1
+}
+
+// lexTag returns a tag token of type tt which starts at the beginning of s. The tag's name starts at the index i.
+func lexTag(s string, i int, tt TokenType, eof bool) (tok Token, attr string, n int) {
+	tok.Type = tt
+
+	// the name lasts until a white space, '/' or '>'
+	nameEnd := i
+	for nameEnd < len(s) && isValidAttrNameChar(s[nameEnd]) {
+		nameEnd++
+	}
+
+	// localize the closure of the tag, skipping quoted attribute values
+	end, selfClosing := tagEnd(s[nameEnd:])
+	if end == -1 {
+		if !eof {
+			return tok, "", 0
+		}
+		// a tag which is not closed until the end of the document is ignored
+		tok.Type = ignoredToken
+		return tok, "", len(s)
+	}
+	end += nameEnd
+
+	tok.Name = toLowerASCII(s[i:nameEnd])
+	if tt == StartTagToken {
+		attr = s[nameEnd : end-1]
+		tok.SelfClosing = selfClosing
+	}
+
+	return tok, attr, end
+}
+
+// toLowerASCII returns s with all ASCII letters changed to lowercase. Other characters are not changed.
+func toLowerASCII(s string) string {
+	for i := 0; i < len(s); i++ {
+		if 'A' <= s[i] && s[i] <= 'Z' {
+			b := []byte(s)
+			for j := i; j < len(b); j++ {
+				if 'A' <= b[j] && b[j] <= 'Z' {
+					b[j] += 'a' - 'A'
+				}
+			}
+			return string(b)
+		}
+	}
+
+	// there are no uppercase letters
+	return s
+}
+
+// isASCIILetter checks if b is an ASCII letter
+func isASCIILetter(b byte) bool {
+	return 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z'
+}
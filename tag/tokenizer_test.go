@@ -0,0 +1,128 @@
+package tag
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// tokenize returns all tokens read by z
+func tokenize(z *Tokenizer) ([]Token, error) {
+	var tokens []Token
+	for {
+		tok, err := z.Next()
+		if err == io.EOF {
+			return tokens, nil
+		}
+		if err != nil {
+			return tokens, err
+		}
+		tokens = append(tokens, tok)
+	}
+}
+
+func TestTokenizer(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want []Token
+	}{
+		{
+			"1",
+			`<!DOCTYPE html><p class="x">a < b</p><!-- <a> --><br/>`,
+			[]Token{
+				{Type: DoctypeToken, Data: "<!DOCTYPE html>", Start: 0, End: 15},
+				{Type: StartTagToken, Name: "p", Attr: map[string]string{"class": "x"}, Data: `<p class="x">`, Start: 15, End: 28},
+				{Type: TextToken, Data: "a < b", Start: 28, End: 33},
+				{Type: EndTagToken, Name: "p", Data: "</p>", Start: 33, End: 37},
+				{Type: CommentToken, Data: "<!-- <a> -->", Start: 37, End: 49},
+				{Type: StartTagToken, Name: "br", Attr: map[string]string{}, Data: "<br/>", SelfClosing: true, Start: 49, End: 54},
+			},
+		},
+		{
+			"2",
+			`a</>b<?xml?><!-->c<!--->d</ x>e<`,
+			[]Token{
+				{Type: TextToken, Data: "a", Start: 0, End: 1},
+				{Type: TextToken, Data: "b", Start: 4, End: 5},
+				{Type: CommentToken, Data: "<?xml?>", Start: 5, End: 12},
+				{Type: CommentToken, Data: "<!-->", Start: 12, End: 17},
+				{Type: TextToken, Data: "c", Start: 17, End: 18},
+				{Type: CommentToken, Data: "<!--->", Start: 18, End: 24},
+				{Type: TextToken, Data: "d", Start: 24, End: 25},
+				{Type: CommentToken, Data: "</ x>", Start: 25, End: 30},
+				{Type: TextToken, Data: "e<", Start: 30, End: 32},
+			},
+		},
+		{
+			"3",
+			`<a href="x"`,
+			nil,
+		},
+		{
+			"4",
+			`text<!-- not closed`,
+			[]Token{
+				{Type: TextToken, Data: "text", Start: 0, End: 4},
+				{Type: CommentToken, Data: "<!-- not closed", Start: 4, End: 19},
+			},
+		},
+		{
+			"5",
+			`<script><a></SCRIPT ><![CDATA[<a>]]></scrip`,
+			[]Token{
+				{Type: StartTagToken, Name: "script", Attr: map[string]string{}, Data: "<script>", Start: 0, End: 8},
+				{Type: TextToken, Data: "<a>", Start: 8, End: 11},
+				{Type: EndTagToken, Name: "script", Data: "</SCRIPT >", Start: 11, End: 21},
+				{Type: CDATAToken, Data: "<![CDATA[<a>]]>", Start: 21, End: 36},
+			},
+		},
+		{
+			"6",
+			`<style></style><textarea></textarea x><plaintext></plaintext>`,
+			[]Token{
+				{Type: StartTagToken, Name: "style", Attr: map[string]string{}, Data: "<style>", Start: 0, End: 7},
+				{Type: EndTagToken, Name: "style", Data: "</style>", Start: 7, End: 15},
+				{Type: StartTagToken, Name: "textarea", Attr: map[string]string{}, Data: "<textarea>", Start: 15, End: 25},
+				{Type: EndTagToken, Name: "textarea", Data: "</textarea x>", Start: 25, End: 38},
+				{Type: StartTagToken, Name: "plaintext", Attr: map[string]string{}, Data: "<plaintext>", Start: 38, End: 49},
+				{Type: TextToken, Data: "</plaintext>", Start: 49, End: 61},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the result must not depend on how the document is read
+			readers := map[string]io.Reader{
+				"whole":   strings.NewReader(tt.doc),
+				"onebyte": iotest.OneByteReader(strings.NewReader(tt.doc)),
+				"dataerr": iotest.DataErrReader(strings.NewReader(tt.doc)),
+			}
+			for rn, r := range readers {
+				got, err := tokenize(NewTokenizer(r))
+				if err != nil {
+					t.Fatalf("%s: Tokenizer.Next() error = %v", rn, err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("%s: Tokenizer.Next() = %v, want %v", rn, got, tt.want)
+				}
+			}
+		})
+	}
+
+	t.Run("error", func(t *testing.T) {
+		errRead := errors.New("read error")
+		r := io.MultiReader(strings.NewReader("<a>text"), iotest.ErrReader(errRead))
+		got, err := tokenize(NewTokenizer(r))
+		if err != errRead {
+			t.Errorf("Tokenizer.Next() error = %v, want %v", err, errRead)
+		}
+		want := []Token{{Type: StartTagToken, Name: "a", Attr: map[string]string{}, Data: "<a>", Start: 0, End: 3}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Tokenizer.Next() = %v, want %v", got, want)
+		}
+	})
+}